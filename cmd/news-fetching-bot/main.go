@@ -5,20 +5,31 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/to77e/news-fetching-bot/internal/admin"
+	"github.com/to77e/news-fetching-bot/internal/api"
 	"github.com/to77e/news-fetching-bot/internal/bot"
 	"github.com/to77e/news-fetching-bot/internal/botkit"
 	"github.com/to77e/news-fetching-bot/internal/config"
+	"github.com/to77e/news-fetching-bot/internal/dashboard"
 	"github.com/to77e/news-fetching-bot/internal/database"
+	"github.com/to77e/news-fetching-bot/internal/feed"
 	"github.com/to77e/news-fetching-bot/internal/fetcher"
+	"github.com/to77e/news-fetching-bot/internal/metrics"
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/newsletter"
 	"github.com/to77e/news-fetching-bot/internal/notifier"
+	"github.com/to77e/news-fetching-bot/internal/publisher"
 	"github.com/to77e/news-fetching-bot/internal/repository"
+	"github.com/to77e/news-fetching-bot/internal/server"
 	"github.com/to77e/news-fetching-bot/internal/summary"
+	"github.com/to77e/news-fetching-bot/internal/webhook"
 )
 
 func main() {
@@ -58,6 +69,11 @@ func main() {
 
 	articleRepository := repository.NewArticleRepository(conn)
 	sourceRepository := repository.NewSourceRepository(conn)
+	subscriptionRepository := repository.NewSubscriptionRepository(conn)
+	deliveryRepository := repository.NewDeliveryRepository(conn)
+	voteRepository := repository.NewVoteRepository(conn)
+	templateRepository := repository.NewTemplateRepository(conn)
+	filterRepository := repository.NewFilterRepository(conn)
 	var (
 		fetch = fetcher.New(
 			articleRepository,
@@ -72,6 +88,7 @@ func main() {
 		)
 		notify = notifier.New(
 			articleRepository,
+			deliveryRepository,
 			summarize,
 			botAPI,
 			cfg.Settings.NotificationInterval,
@@ -80,17 +97,321 @@ func main() {
 		)
 	)
 
+	summarize.SetPrices(cfg.OpenAI.PromptPrice, cfg.OpenAI.CompletionPrice)
+	fetch.SetFilters(filterRepository)
+	notify.SetSubscriptions(subscriptionRepository, cfg.Settings.DigestInterval)
+	moderationChatID := cfg.Moderation.ChatID
+	if moderationChatID == 0 {
+		moderationChatID = cfg.Telegram.AdminChatID
+	}
+	if cfg.Moderation.Enabled {
+		notify.SetModeration(moderationChatID)
+	}
+	if cfg.Settings.FeedbackEnabled {
+		notify.SetFeedback(voteRepository)
+	}
+	notify.SetTemplates(templateRepository, sourceRepository)
+	notify.SetPostOptions(notifier.PostOptions{
+		Images:     cfg.Posts.ImagesEnabled,
+		LinkButton: cfg.Posts.LinkButtonEnabled,
+		DiscussURL: cfg.Posts.DiscussURL,
+	})
+	if cfg.Publishers.SlackWebhookURL != "" {
+		notify.AddPublisher(publisher.NewSlackPublisher(cfg.Publishers.SlackWebhookURL))
+	}
+	if cfg.Publishers.DiscordWebhookURL != "" {
+		notify.AddPublisher(publisher.NewDiscordPublisher(cfg.Publishers.DiscordWebhookURL))
+	}
+	if cfg.Publishers.MattermostWebhookURL != "" {
+		notify.AddPublisher(publisher.NewMattermostPublisher(cfg.Publishers.MattermostWebhookURL))
+	}
+	for destination, spec := range map[string]string{
+		models.DestinationTelegram:   cfg.Telegram.ChannelSchedule,
+		models.DestinationSlack:      cfg.Publishers.SlackSchedule,
+		models.DestinationDiscord:    cfg.Publishers.DiscordSchedule,
+		models.DestinationMattermost: cfg.Publishers.MattermostSchedule,
+	} {
+		if spec == "" {
+			continue
+		}
+		schedule, err := notifier.ParseSchedule(spec)
+		if err != nil {
+			slog.With("error", err.Error()).ErrorContext(ctx, "parse schedule", "destination", destination)
+			return
+		}
+		notify.SetSchedule(destination, schedule)
+	}
+	if cfg.Scoring.Enabled {
+		summarize.SetRelevancePrompt(cfg.Scoring.RelevancePrompt)
+		notify.SetRanker(notifier.NewRanker(cfg.Scoring.Candidates, cfg.Scoring.Weights, map[string]notifier.Scorer{
+			notifier.ScoreRecency:    notifier.RecencyScorer(cfg.Scoring.RecencyHalfLife),
+			notifier.ScorePriority:   notifier.SourcePriorityScorer(sourceRepository),
+			notifier.ScoreKeywords:   notifier.KeywordScorer(cfg.Scoring.Keywords),
+			notifier.ScoreRelevance:  notifier.RelevanceScorer(summarize),
+			notifier.ScoreDuplicates: notifier.DuplicateScorer(articleRepository),
+			notifier.ScoreQuality:    notifier.SourceQualityScorer(voteRepository),
+		}), articleRepository)
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
+	// the public HTTP server is started only if something is served on it
+	mux := http.NewServeMux()
+	var publicServer bool
+
+	if len(cfg.Webhooks.URLs) > 0 {
+		dispatcher := webhook.NewDispatcher(
+			cfg.Webhooks.URLs,
+			cfg.Webhooks.Secret,
+			cfg.Webhooks.Events,
+			repository.NewWebhookRepository(conn),
+		)
+		dispatcher.SetRetries(cfg.Webhooks.MaxAttempts, cfg.Webhooks.RetryDelay)
+		fetch.SetEvents(dispatcher)
+		notify.SetEvents(dispatcher)
+
+		go func(ctx context.Context) {
+			if err := dispatcher.Start(ctx); err != nil {
+				if errors.Is(err, context.Canceled) {
+					slog.With("error", err.Error()).Error("webhook dispatcher start")
+					return
+				}
+				slog.With("error", err.Error()).Error("webhook dispatcher stop")
+			}
+		}(ctx)
+	}
+
+	if cfg.Feed.Enabled {
+		feed.NewHandler(
+			articleRepository,
+			cfg.Feed.Title,
+			cfg.Feed.Description,
+			cfg.HTTP.PublicURL,
+			cfg.Feed.PageSize,
+		).Register(mux)
+		publicServer = true
+	}
+
+	if cfg.Newsletter.Enabled {
+		newsletterRepository := repository.NewNewsletterRepository(conn)
+		digest := newsletter.New(
+			articleRepository,
+			sourceRepository,
+			newsletterRepository,
+			newsletter.NewSMTPMailer(cfg.Newsletter.SMTP.Host, cfg.Newsletter.SMTP.Port, cfg.Newsletter.SMTP.Username, cfg.Newsletter.SMTP.Password),
+			cfg.Newsletter.From,
+			cfg.HTTP.PublicURL,
+			cfg.Newsletter.Interval,
+			cfg.Newsletter.Size,
+		)
+		if err := digest.SyncRecipients(ctx, cfg.Newsletter.Recipients); err != nil {
+			slog.With("error", err.Error()).ErrorContext(ctx, "sync newsletter recipients")
+			return
+		}
+		mux.Handle(newsletter.UnsubscribePath, newsletter.UnsubscribeHandler(newsletterRepository))
+		publicServer = true
+
+		go func(ctx context.Context) {
+			if err := digest.Start(ctx); err != nil {
+				if errors.Is(err, context.Canceled) {
+					slog.With("error", err.Error()).Error("newsletter start")
+					return
+				}
+				slog.With("error", err.Error()).Error("newsletter stop")
+			}
+		}(ctx)
+	}
+
+	if publicServer {
+		go func(ctx context.Context) {
+			if err := server.Run(ctx, cfg.HTTP.ListenAddr, mux); err != nil {
+				if errors.Is(err, context.Canceled) {
+					slog.With("error", err.Error()).Error("http server start")
+					return
+				}
+				slog.With("error", err.Error()).Error("http server stop")
+			}
+		}(ctx)
+	}
+
+	adminMux := http.NewServeMux()
+	admin.NewHandler(
+		conn,
+		botAPI,
+		fetch,
+		notify,
+		summarize,
+		cfg.Settings.FetchInterval,
+		cfg.Settings.NotificationInterval,
+	).Register(adminMux)
+	metrics.RegisterQueue(notify)
+	adminMux.Handle(metrics.Path, metrics.Handler())
+	if len(cfg.API.Tokens) > 0 {
+		api.NewHandler(
+			cfg.API.Tokens,
+			sourceRepository,
+			articleRepository,
+			deliveryRepository,
+			fetch,
+			notify,
+		).Register(adminMux)
+	}
+	if cfg.Dashboard.Enabled {
+		if cfg.Dashboard.Password == "" && !cfg.Dashboard.TelegramLogin {
+			slog.ErrorContext(ctx, "dashboard requires DASHBOARD_PASSWORD or DASHBOARD_TELEGRAM_LOGIN")
+			return
+		}
+
+		web := dashboard.New(
+			sourceRepository,
+			articleRepository,
+			deliveryRepository,
+			filterRepository,
+			fetch,
+			notify,
+			cfg.Dashboard.SessionSecret,
+			cfg.Dashboard.SessionTTL,
+		)
+		if cfg.Dashboard.Password != "" {
+			web.SetPasswordLogin(cfg.Dashboard.Password)
+		}
+		if cfg.Dashboard.TelegramLogin {
+			web.SetTelegramLogin(cfg.Telegram.BotToken, botAPI.Self.UserName, cfg.Telegram.AdminIDs)
+		}
+		web.SetSecureCookie(cfg.Dashboard.SecureCookie)
+		web.Register(adminMux)
+	}
+
+	go func(ctx context.Context) {
+		if err := server.Run(ctx, cfg.Admin.ListenAddr, adminMux); err != nil {
+			if errors.Is(err, context.Canceled) {
+				slog.With("error", err.Error()).Error("admin server start")
+				return
+			}
+			slog.With("error", err.Error()).Error("admin server stop")
+		}
+	}(ctx)
+
 	newsBot := botkit.New(botAPI)
-	newsBot.RegisterCmdView("start", bot.ViewCmdStart())
-	newsBot.RegisterCmdView("add_source", bot.ViewCmdAddSource(sourceRepository))
-	newsBot.RegisterCmdView("list_sources", bot.ViewCmdListSources(sourceRepository))
-	// command help should be registered last
-	newsBot.RegisterCmdView("help", bot.ViewCmdHelp(newsBot.GetCommandNames()))
-	// hidden commands
-	newsBot.RegisterCmdView("info", bot.ViewCmdInfo(cfg.Project.Version, cfg.Project.CommitHash))
+	newsBot.SetConcurrency(cfg.Telegram.Workers, cfg.Telegram.WorkerQueueSize, cfg.Telegram.DrainTimeout)
+	if cfg.Telegram.ConversationStore == "postgres" {
+		newsBot.SetConversationStore(repository.NewConversationRepository(conn), cfg.Telegram.ConversationTTL)
+	} else {
+		newsBot.SetConversationStore(botkit.NewMemoryConversationStore(), cfg.Telegram.ConversationTTL)
+	}
+	newsBot.SetAdmins(cfg.Telegram.AdminIDs, cfg.Telegram.AdminChatID)
+	newsBot.SetObserver(metrics.BotObserver{})
+	newsBot.RegisterCmdView("start", bot.ViewCmdStart(), botkit.Command{
+		Description:  "Start the bot",
+		Descriptions: map[string]string{"ru": "Запустить бота"},
+	})
+	newsBot.RegisterCmdView("help", bot.ViewCmdHelp(newsBot), botkit.Command{
+		Description:  "Show the list of commands",
+		Descriptions: map[string]string{"ru": "Показать список команд"},
+	})
+	newsBot.RegisterCmdView("add_source", bot.ViewCmdAddSource(sourceRepository), botkit.Command{
+		Description:  "Add a feed source",
+		Usage:        `[{"name": "...", "url": "...", "priority": 0}]`,
+		Role:         botkit.RoleAdmin,
+		Descriptions: map[string]string{"ru": "Добавить источник"},
+	})
+	newsBot.RegisterConversation("add_source", bot.ConversationAddSource(sourceRepository))
+	newsBot.RegisterCmdView(botkit.CancelCommand, newsBot.ViewCmdCancel(), botkit.Command{
+		Description:  "Cancel the current command",
+		Descriptions: map[string]string{"ru": "Отменить текущую команду"},
+	})
+	newsBot.RegisterCmdView("list_sources", bot.ViewCmdListSources(sourceRepository, voteRepository), botkit.Command{
+		Description:  "List feed sources",
+		Descriptions: map[string]string{"ru": "Список источников"},
+	})
+	newsBot.RegisterCallbackView(bot.CallbackListSources, bot.CallbackListSourcesVersion, bot.ViewCallbackListSources(sourceRepository, voteRepository), botkit.RoleUser)
+	searchQueries := bot.NewSearchQueries()
+	newsBot.RegisterCmdView("search", bot.ViewCmdSearch(articleRepository, searchQueries), botkit.Command{
+		Description:  "Search articles",
+		Usage:        "<query> [source:<ID>] [from:YYYY-MM-DD] [to:YYYY-MM-DD]",
+		Descriptions: map[string]string{"ru": "Поиск статей"},
+	})
+	newsBot.RegisterCallbackView(bot.CallbackSearch, bot.CallbackSearchVersion, bot.ViewCallbackSearch(articleRepository, searchQueries), botkit.RoleUser)
+	newsBot.RegisterCmdView("latest", bot.ViewCmdLatest(articleRepository, sourceRepository), botkit.Command{
+		Description:  "Show the latest fetched articles",
+		Usage:        "[source ID or name]",
+		Descriptions: map[string]string{"ru": "Последние статьи"},
+	})
+	newsBot.RegisterCallbackView(bot.CallbackLatest, bot.CallbackLatestVersion, bot.ViewCallbackLatest(articleRepository, sourceRepository), botkit.RoleUser)
+	newsBot.RegisterCmdView("article", bot.ViewCmdArticle(articleRepository, sourceRepository, deliveryRepository), botkit.Command{
+		Description:  "Show article details",
+		Usage:        "<ID>",
+		Descriptions: map[string]string{"ru": "Подробности статьи"},
+	})
+	newsBot.RegisterCmdView("why", bot.ViewCmdWhy(articleRepository), botkit.Command{
+		Description:  "Explain the score of an article",
+		Usage:        "<ID>",
+		Descriptions: map[string]string{"ru": "Объяснить оценку статьи"},
+	})
+	newsBot.RegisterCmdView("subscribe", bot.ViewCmdSubscribe(subscriptionRepository, sourceRepository), botkit.Command{
+		Description:  "Subscribe to a source, tag or keywords",
+		Usage:        "source <ID> | tag <tag> | query <keywords>",
+		Descriptions: map[string]string{"ru": "Подписаться на источник, тег или ключевые слова"},
+	})
+	newsBot.RegisterCmdView("subscriptions", bot.ViewCmdSubscriptions(subscriptionRepository), botkit.Command{
+		Description:  "List your subscriptions",
+		Descriptions: map[string]string{"ru": "Список подписок"},
+	})
+	newsBot.RegisterCmdView("unsubscribe", bot.ViewCmdUnsubscribe(subscriptionRepository), botkit.Command{
+		Description:  "Remove a subscription",
+		Usage:        "<ID>",
+		Descriptions: map[string]string{"ru": "Удалить подписку"},
+	})
+	newsBot.RegisterCmdView("delivery", bot.ViewCmdDelivery(subscriptionRepository), botkit.Command{
+		Description:  "Receive subscriptions instantly or as a digest",
+		Usage:        "instant|digest",
+		Descriptions: map[string]string{"ru": "Получать подписки сразу или дайджестом"},
+	})
+	newsBot.RegisterCallbackView(
+		notifier.CallbackModeration,
+		notifier.CallbackModerationVersion,
+		bot.ViewCallbackModeration(articleRepository, notify, newsBot),
+		botkit.RoleAdmin,
+	)
+	if cfg.Moderation.Enabled {
+		// the editors in the moderation chat may not be admins
+		newsBot.AllowCallbackChat(notifier.CallbackModeration, moderationChatID)
+	}
+	newsBot.RegisterCallbackView(notifier.CallbackVote, notifier.CallbackVoteVersion, bot.ViewCallbackVote(voteRepository), botkit.RoleUser)
+	newsBot.RegisterCmdView(bot.ConversationEditSummaryName, bot.ViewCmdEditSummary(newsBot), botkit.Command{
+		Description:  "Edit the summary of an article",
+		Usage:        "[article ID]",
+		Role:         botkit.RoleAdmin,
+		Descriptions: map[string]string{"ru": "Изменить описание статьи"},
+	})
+	newsBot.RegisterConversation(bot.ConversationEditSummaryName, bot.ConversationEditSummary(articleRepository, notify))
+	newsBot.RegisterCmdView("retract", bot.ViewCmdRetract(notify), botkit.Command{
+		Description:  "Delete the posted messages of an article",
+		Usage:        "<article ID>",
+		Role:         botkit.RoleAdmin,
+		Descriptions: map[string]string{"ru": "Удалить опубликованную статью"},
+	})
+	newsBot.RegisterCmdView("set_template", bot.ViewCmdSetTemplate(templateRepository, sourceRepository, cfg.Telegram.ChannelID), botkit.Command{
+		Description:  "Set the post template of the channel or a source",
+		Usage:        "channel|source <ID> [markdown|html]",
+		Role:         botkit.RoleAdmin,
+		Descriptions: map[string]string{"ru": "Задать шаблон постов канала или источника"},
+	})
+	newsBot.RegisterCmdView("preview_template", bot.ViewCmdPreviewTemplate(templateRepository, sourceRepository, cfg.Telegram.ChannelID), botkit.Command{
+		Description:  "Preview the post template on a sample article",
+		Usage:        "channel|source <ID> [markdown|html]",
+		Role:         botkit.RoleAdmin,
+		Descriptions: map[string]string{"ru": "Предпросмотр шаблона постов"},
+	})
+	newsBot.RegisterCmdView("info", bot.ViewCmdInfo(cfg.Project.Version, cfg.Project.CommitHash), botkit.Command{
+		Description: "Show version information",
+		Hidden:      true,
+	})
+
+	if err := newsBot.SyncCommands(); err != nil {
+		slog.With("error", err.Error()).ErrorContext(ctx, "sync bot commands")
+	}
 
 	go func(ctx context.Context) {
 		if err := fetch.Start(ctx); err != nil {
@@ -112,7 +433,19 @@ func main() {
 		}
 	}(ctx)
 
-	if err := newsBot.Run(ctx); err != nil {
+	runBot := newsBot.Run
+	if cfg.Telegram.UpdatesMode == "webhook" {
+		runBot = func(ctx context.Context) error {
+			return newsBot.RunWebhook(ctx, botkit.WebhookConfig{
+				URL:         cfg.Telegram.Webhook.URL,
+				ListenAddr:  cfg.Telegram.Webhook.ListenAddr,
+				Path:        cfg.Telegram.Webhook.Path,
+				SecretToken: cfg.Telegram.Webhook.SecretToken,
+			})
+		}
+	}
+
+	if err := runBot(ctx); err != nil {
 		if errors.Is(err, context.Canceled) {
 			slog.With("error", err.Error()).Error("bot start")
 			return
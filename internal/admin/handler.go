@@ -0,0 +1,297 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	HealthPath = "/healthz"
+	ReadyPath  = "/readyz"
+	StatusPath = "/status"
+
+	checkTimeout = 5 * time.Second
+
+	// staleFactor is the number of intervals after which the last completed
+	// fetch or notification is too old for the bot to be ready.
+	staleFactor = 3
+
+	statusOK          = "ok"
+	statusError       = "error"
+	statusUnavailable = "unavailable"
+	statusFailing     = "failing"
+)
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type FetcherStatus interface {
+	LastFetch() time.Time
+	SourceHealth() []models.SourceHealth
+}
+
+type NotifierStatus interface {
+	LastNotify() time.Time
+	Steps() []models.NotifyStep
+	QueueStats(ctx context.Context) (models.QueueStats, error)
+}
+
+type SummarizerStatus interface {
+	Status() models.SummarizerStatus
+}
+
+// Handler serves the liveness, readiness and status endpoints of the admin
+// HTTP server.
+type Handler struct {
+	db         Pinger
+	bot        *tgbotapi.BotAPI
+	fetcher    FetcherStatus
+	notifier   NotifierStatus
+	summarizer SummarizerStatus
+
+	fetchInterval  time.Duration
+	notifyInterval time.Duration
+}
+
+func NewHandler(
+	db Pinger,
+	bot *tgbotapi.BotAPI,
+	fetcher FetcherStatus,
+	notifier NotifierStatus,
+	summarizer SummarizerStatus,
+	fetchInterval time.Duration,
+	notifyInterval time.Duration,
+) *Handler {
+	return &Handler{
+		db:             db,
+		bot:            bot,
+		fetcher:        fetcher,
+		notifier:       notifier,
+		summarizer:     summarizer,
+		fetchInterval:  fetchInterval,
+		notifyInterval: notifyInterval,
+	}
+}
+
+// Register registers the endpoints on the mux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc(HealthPath, h.health)
+	mux.HandleFunc(ReadyPath, h.ready)
+	mux.HandleFunc(StatusPath, h.status)
+}
+
+type check struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+type readiness struct {
+	Status string  `json:"status"`
+	Checks []check `json:"checks"`
+}
+
+// health reports that the process is alive, it doesn't check dependencies.
+func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": statusOK})
+}
+
+// ready reports whether the database and Telegram are reachable and the
+// fetcher and the notifier completed recently.
+func (h *Handler) ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	checks := []check{
+		newCheck("database", h.db.Ping(ctx)),
+		newCheck("telegram", h.getMe(ctx)),
+		freshnessCheck("fetcher", h.fetcher.LastFetch(), h.fetchInterval),
+		freshnessCheck("notifier", h.notifier.LastNotify(), h.notifyInterval),
+	}
+
+	resp := readiness{Status: statusOK, Checks: checks}
+	code := http.StatusOK
+	for _, v := range checks {
+		if v.Status != statusOK {
+			resp.Status = statusUnavailable
+			code = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	writeJSON(w, code, resp)
+}
+
+// getMe checks the bot token with the Telegram API. The library doesn't
+// accept a context, so the request is abandoned on timeout.
+func (h *Handler) getMe(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := h.bot.GetMe()
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newCheck(name string, err error) check {
+	if err != nil {
+		return check{Name: name, Status: statusError, Error: err.Error()}
+	}
+	return check{Name: name, Status: statusOK}
+}
+
+func freshnessCheck(name string, last time.Time, interval time.Duration) check {
+	c := check{Name: name, Status: statusOK, LastSuccess: timePtr(last)}
+
+	switch {
+	case last.IsZero():
+		c.Status = statusError
+		c.Error = "not completed yet"
+	case time.Since(last) > staleFactor*interval:
+		c.Status = statusError
+		c.Error = fmt.Sprintf("last completed %s ago", time.Since(last).Round(time.Second))
+	}
+
+	return c
+}
+
+type status struct {
+	LastFetch  *time.Time       `json:"last_fetch,omitempty"`
+	LastNotify *time.Time       `json:"last_notify,omitempty"`
+	Notifier   []stepStatus     `json:"notifier"`
+	Sources    []sourceStatus   `json:"sources"`
+	Queue      *queueStatus     `json:"queue,omitempty"`
+	QueueError string           `json:"queue_error,omitempty"`
+	Summarizer summarizerStatus `json:"summarizer"`
+}
+
+type sourceStatus struct {
+	ID                  int64      `json:"id"`
+	Name                string     `json:"name"`
+	Status              string     `json:"status"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Items               int        `json:"items"`
+}
+
+type stepStatus struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+type queueStatus struct {
+	NotPosted int `json:"not_posted"`
+	Pending   int `json:"pending_moderation"`
+	Approved  int `json:"approved"`
+}
+
+type summarizerStatus struct {
+	Enabled     bool       `json:"enabled"`
+	Model       string     `json:"model"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// status summarizes the health of the sources and the notifier steps, the
+// posting queue and the state of the summarizer.
+func (h *Handler) status(w http.ResponseWriter, r *http.Request) {
+	resp := status{
+		LastFetch:  timePtr(h.fetcher.LastFetch()),
+		LastNotify: timePtr(h.notifier.LastNotify()),
+		Notifier:   []stepStatus{},
+		Sources:    []sourceStatus{},
+	}
+
+	for _, v := range h.notifier.Steps() {
+		step := stepStatus{
+			Name:        v.Name,
+			Status:      statusOK,
+			LastSuccess: timePtr(v.LastSuccessDate),
+			LastFailure: timePtr(v.LastFailureDate),
+			LastError:   v.LastError,
+		}
+		if v.LastError != "" {
+			step.Status = statusFailing
+		}
+		resp.Notifier = append(resp.Notifier, step)
+	}
+
+	for _, v := range h.fetcher.SourceHealth() {
+		source := sourceStatus{
+			ID:                  v.SourceID,
+			Name:                v.Name,
+			Status:              statusOK,
+			LastSuccess:         timePtr(v.LastSuccessDate),
+			LastFailure:         timePtr(v.LastFailureDate),
+			LastError:           v.LastError,
+			ConsecutiveFailures: v.ConsecutiveFailures,
+			Items:               v.LastItems,
+		}
+		if v.ConsecutiveFailures > 0 {
+			source.Status = statusFailing
+		}
+		resp.Sources = append(resp.Sources, source)
+	}
+
+	queue, err := h.notifier.QueueStats(r.Context())
+	if err != nil {
+		slog.With("error", err.Error()).ErrorContext(r.Context(), "count queued articles")
+		resp.QueueError = err.Error()
+	} else {
+		resp.Queue = &queueStatus{
+			NotPosted: queue.NotPosted,
+			Pending:   queue.Pending,
+			Approved:  queue.Approved,
+		}
+	}
+
+	summarizer := h.summarizer.Status()
+	resp.Summarizer = summarizerStatus{
+		Enabled:     summarizer.Enabled,
+		Model:       summarizer.Model,
+		LastSuccess: timePtr(summarizer.LastSuccessDate),
+		LastFailure: timePtr(summarizer.LastFailureDate),
+		LastError:   summarizer.LastError,
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.With("error", err.Error()).Error("write json response")
+	}
+}
+
+// timePtr omits the zero time from the JSON responses.
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
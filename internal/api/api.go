@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/notifier"
+	"github.com/to77e/news-fetching-bot/internal/repository"
+)
+
+const (
+	PathPrefix = "/api/v1/"
+
+	defaultPageSize = 20
+	maxPageSize     = 100
+	maxBodySize     = 1 << 20
+)
+
+type SourceRepository interface {
+	Sources(ctx context.Context) ([]*models.Source, error)
+	SourceByID(ctx context.Context, id int64) (*models.Source, error)
+	Add(ctx context.Context, source models.Source) (int64, error)
+	Update(ctx context.Context, source models.Source) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type ArticleRepository interface {
+	ArticleByID(ctx context.Context, id int64) (*models.Article, error)
+	Latest(ctx context.Context, q models.ArticleQuery) ([]*models.Article, int, error)
+	Search(ctx context.Context, q models.ArticleQuery) ([]*models.Article, int, error)
+}
+
+type DeliveryRepository interface {
+	DeliveriesByArticle(ctx context.Context, articleID int64) ([]*models.Delivery, error)
+	Latest(ctx context.Context, destination string, limit, offset uint64) ([]*models.Delivery, int, error)
+}
+
+type SourceFetcher interface {
+	FetchSource(ctx context.Context, id int64) (models.SourceHealth, error)
+}
+
+type ArticlePoster interface {
+	PostArticle(ctx context.Context, id int64) error
+}
+
+// endpoint handles a request to a route, id is the ID in the path, if any.
+type endpoint func(w http.ResponseWriter, r *http.Request, id int64) error
+
+// Handler serves the JSON REST API for sources, articles and deliveries.
+// The requests are authenticated with one of the API tokens in the
+// Authorization header: "Bearer <token>".
+type Handler struct {
+	tokens     [][]byte
+	sources    SourceRepository
+	articles   ArticleRepository
+	deliveries DeliveryRepository
+	fetcher    SourceFetcher
+	poster     ArticlePoster
+
+	// routes are the endpoints by path, where {id} is the ID, and method
+	routes map[string]map[string]endpoint
+}
+
+func NewHandler(
+	tokens []string,
+	sources SourceRepository,
+	articles ArticleRepository,
+	deliveries DeliveryRepository,
+	fetcher SourceFetcher,
+	poster ArticlePoster,
+) *Handler {
+	h := &Handler{
+		sources:    sources,
+		articles:   articles,
+		deliveries: deliveries,
+		fetcher:    fetcher,
+		poster:     poster,
+	}
+	for _, v := range tokens {
+		if v = strings.TrimSpace(v); v != "" {
+			h.tokens = append(h.tokens, []byte(v))
+		}
+	}
+
+	h.routes = map[string]map[string]endpoint{
+		"sources": {
+			http.MethodGet:  h.listSources,
+			http.MethodPost: h.createSource,
+		},
+		"sources/{id}": {
+			http.MethodGet:    h.getSource,
+			http.MethodPut:    h.updateSource,
+			http.MethodDelete: h.deleteSource,
+		},
+		"sources/{id}/fetch": {
+			http.MethodPost: h.fetchSource,
+		},
+		"articles": {
+			http.MethodGet: h.listArticles,
+		},
+		"articles/{id}": {
+			http.MethodGet: h.getArticle,
+		},
+		"articles/{id}/post": {
+			http.MethodPost: h.postArticle,
+		},
+		"articles/{id}/deliveries": {
+			http.MethodGet: h.articleDeliveries,
+		},
+		"deliveries": {
+			http.MethodGet: h.listDeliveries,
+		},
+	}
+
+	return h
+}
+
+// Register registers the handler on the path prefix of the API.
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.Handle(PathPrefix, h)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+		writeError(w, http.StatusUnauthorized, "invalid API token")
+		return
+	}
+
+	route, id, ok := parsePath(strings.TrimPrefix(r.URL.Path, PathPrefix))
+	methods, found := h.routes[route]
+	if !ok || !found {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	handle, ok := methods[r.Method]
+	if !ok {
+		allowed := make([]string, 0, len(methods))
+		for method := range methods {
+			allowed = append(allowed, method)
+		}
+		sort.Strings(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if err := handle(w, r, id); err != nil {
+		h.writeErr(w, r, err)
+	}
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+
+	for _, v := range h.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), v) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePath replaces the ID in the second segment of the path with {id}.
+func parsePath(path string) (string, int64, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 {
+		return parts[0], 0, true
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || id <= 0 {
+		return "", 0, false
+	}
+	parts[1] = "{id}"
+
+	return strings.Join(parts, "/"), id, true
+}
+
+// requestError is an error caused by the request, its message is returned to
+// the client.
+type requestError struct {
+	code    int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+func badRequest(message string) error {
+	return &requestError{code: http.StatusBadRequest, message: message}
+}
+
+func (h *Handler) writeErr(w http.ResponseWriter, r *http.Request, err error) {
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &reqErr):
+		writeError(w, reqErr.code, reqErr.message)
+	case errors.Is(err, repository.ErrorSourceNotFound):
+		writeError(w, http.StatusNotFound, "source not found")
+	case errors.Is(err, repository.ErrorArticleNotFound):
+		writeError(w, http.StatusNotFound, "article not found")
+	case errors.Is(err, notifier.ErrAlreadyPosted), errors.Is(err, notifier.ErrAlreadyRetracted):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		slog.With("error", err.Error()).ErrorContext(r.Context(), "api request", "method", r.Method, "path", r.URL.Path)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.With("error", err.Error()).Error("write json response")
+	}
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return badRequest("invalid JSON body: " + err.Error())
+	}
+	return nil
+}
+
+// page is a page of a list with the total number of items.
+type page[T any] struct {
+	Items    []T `json:"items"`
+	Total    int `json:"total"`
+	Page     int `json:"page"`
+	PageSize int `json:"page_size"`
+}
+
+// pagination parses the page and page_size query parameters, pages start
+// from 1.
+func pagination(r *http.Request) (number, size int, err error) {
+	number, size = 1, defaultPageSize
+
+	query := r.URL.Query()
+	if value := query.Get("page"); value != "" {
+		if number, err = strconv.Atoi(value); err != nil || number < 1 {
+			return 0, 0, badRequest("page must be a positive number")
+		}
+	}
+	if value := query.Get("page_size"); value != "" {
+		if size, err = strconv.Atoi(value); err != nil || size < 1 || size > maxPageSize {
+			return 0, 0, badRequest("page_size must be a number from 1 to " + strconv.Itoa(maxPageSize))
+		}
+	}
+
+	return number, size, nil
+}
+
+func newPage[T any](items []T, total, number, size int) page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return page[T]{Items: items, Total: total, Page: number, PageSize: size}
+}
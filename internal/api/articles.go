@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const dateFmt = "2006-01-02"
+
+// listArticles returns a page of the latest articles or, with the q query
+// parameter, of the articles matching the full-text query. Both are filtered
+// by the source, from and to parameters, the dates are inclusive.
+func (h *Handler) listArticles(w http.ResponseWriter, r *http.Request, _ int64) error {
+	number, size, err := pagination(r)
+	if err != nil {
+		return err
+	}
+
+	params := r.URL.Query()
+	q := models.ArticleQuery{
+		Text:   strings.TrimSpace(params.Get("q")),
+		Limit:  uint64(size),
+		Offset: uint64((number - 1) * size),
+	}
+	if value := params.Get("source"); value != "" {
+		if q.SourceID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return badRequest("source must be a source ID")
+		}
+	}
+	if value := params.Get("from"); value != "" {
+		if q.From, err = time.Parse(dateFmt, value); err != nil {
+			return badRequest("from must be a date in the YYYY-MM-DD format")
+		}
+	}
+	if value := params.Get("to"); value != "" {
+		if q.To, err = time.Parse(dateFmt, value); err != nil {
+			return badRequest("to must be a date in the YYYY-MM-DD format")
+		}
+		q.To = q.To.AddDate(0, 0, 1)
+	}
+
+	list := h.articles.Latest
+	if q.Text != "" {
+		list = h.articles.Search
+	}
+
+	articles, total, err := list(r.Context(), q)
+	if err != nil {
+		return fmt.Errorf("list articles: %w", err)
+	}
+
+	writeJSON(w, http.StatusOK, newPage(mapSlice(articles, newArticle), total, number, size))
+	return nil
+}
+
+func (h *Handler) getArticle(w http.ResponseWriter, r *http.Request, id int64) error {
+	article, err := h.articles.ArticleByID(r.Context(), id)
+	if err != nil {
+		return fmt.Errorf("get article: %w", err)
+	}
+
+	writeJSON(w, http.StatusOK, newArticle(article))
+	return nil
+}
+
+// postArticle posts the article to the channel right away, regardless of
+// the moderation, and returns the posted article.
+func (h *Handler) postArticle(w http.ResponseWriter, r *http.Request, id int64) error {
+	if err := h.poster.PostArticle(r.Context(), id); err != nil {
+		return fmt.Errorf("post article: %w", err)
+	}
+
+	article, err := h.articles.ArticleByID(r.Context(), id)
+	if err != nil {
+		return fmt.Errorf("get article: %w", err)
+	}
+
+	writeJSON(w, http.StatusOK, newArticle(article))
+	return nil
+}
+
+func (h *Handler) articleDeliveries(w http.ResponseWriter, r *http.Request, id int64) error {
+	if _, err := h.articles.ArticleByID(r.Context(), id); err != nil {
+		return fmt.Errorf("get article: %w", err)
+	}
+
+	deliveries, err := h.deliveries.DeliveriesByArticle(r.Context(), id)
+	if err != nil {
+		return fmt.Errorf("list deliveries: %w", err)
+	}
+
+	writeJSON(w, http.StatusOK, mapSlice(deliveries, newDelivery))
+	return nil
+}
+
+// listDeliveries returns a page of the delivery log, most recent first,
+// optionally to the destination in the destination query parameter.
+func (h *Handler) listDeliveries(w http.ResponseWriter, r *http.Request, _ int64) error {
+	number, size, err := pagination(r)
+	if err != nil {
+		return err
+	}
+
+	deliveries, total, err := h.deliveries.Latest(
+		r.Context(),
+		strings.TrimSpace(r.URL.Query().Get("destination")),
+		uint64(size),
+		uint64((number-1)*size),
+	)
+	if err != nil {
+		return fmt.Errorf("list deliveries: %w", err)
+	}
+
+	writeJSON(w, http.StatusOK, newPage(mapSlice(deliveries, newDelivery), total, number, size))
+	return nil
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/repository"
+)
+
+type sourceRequest struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Priority int    `json:"priority"`
+}
+
+// model validates the request with the same rules as the add_source
+// conversation of the bot.
+func (s sourceRequest) model() (models.Source, error) {
+	source := models.Source{
+		Name:     strings.TrimSpace(s.Name),
+		URL:      strings.TrimSpace(s.URL),
+		Priority: s.Priority,
+	}
+
+	if source.Name == "" {
+		return models.Source{}, badRequest("name can't be empty")
+	}
+	u, err := url.Parse(source.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return models.Source{}, badRequest("url must be an http:// or https:// URL")
+	}
+	if source.Priority < 0 || source.Priority > 100 {
+		return models.Source{}, badRequest("priority must be a number from 0 to 100")
+	}
+
+	return source, nil
+}
+
+func (h *Handler) listSources(w http.ResponseWriter, r *http.Request, _ int64) error {
+	sources, err := h.sources.Sources(r.Context())
+	if err != nil {
+		return fmt.Errorf("list sources: %w", err)
+	}
+
+	writeJSON(w, http.StatusOK, mapSlice(sources, newSource))
+	return nil
+}
+
+func (h *Handler) getSource(w http.ResponseWriter, r *http.Request, id int64) error {
+	source, err := h.sources.SourceByID(r.Context(), id)
+	if err != nil {
+		return fmt.Errorf("get source: %w", err)
+	}
+
+	writeJSON(w, http.StatusOK, newSource(source))
+	return nil
+}
+
+func (h *Handler) createSource(w http.ResponseWriter, r *http.Request, _ int64) error {
+	var req sourceRequest
+	if err := readJSON(w, r, &req); err != nil {
+		return err
+	}
+	source, err := req.model()
+	if err != nil {
+		return err
+	}
+
+	id, err := h.sources.Add(r.Context(), source)
+	if err != nil {
+		return fmt.Errorf("add source: %w", err)
+	}
+
+	created, err := h.sources.SourceByID(r.Context(), id)
+	if err != nil {
+		return fmt.Errorf("get source: %w", err)
+	}
+
+	w.Header().Set("Location", PathPrefix+"sources/"+strconv.FormatInt(id, 10))
+	writeJSON(w, http.StatusCreated, newSource(created))
+	return nil
+}
+
+func (h *Handler) updateSource(w http.ResponseWriter, r *http.Request, id int64) error {
+	var req sourceRequest
+	if err := readJSON(w, r, &req); err != nil {
+		return err
+	}
+	source, err := req.model()
+	if err != nil {
+		return err
+	}
+	source.ID = id
+
+	if err := h.sources.Update(r.Context(), source); err != nil {
+		return fmt.Errorf("update source: %w", err)
+	}
+
+	updated, err := h.sources.SourceByID(r.Context(), id)
+	if err != nil {
+		return fmt.Errorf("get source: %w", err)
+	}
+
+	writeJSON(w, http.StatusOK, newSource(updated))
+	return nil
+}
+
+func (h *Handler) deleteSource(w http.ResponseWriter, r *http.Request, id int64) error {
+	if err := h.sources.Delete(r.Context(), id); err != nil {
+		return fmt.Errorf("delete source: %w", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// fetchSource fetches the source right away and returns its health. A failed
+// fetch is reported as a bad gateway with the health of the source.
+func (h *Handler) fetchSource(w http.ResponseWriter, r *http.Request, id int64) error {
+	health, err := h.fetcher.FetchSource(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrorSourceNotFound) {
+			return err
+		}
+
+		writeJSON(w, http.StatusBadGateway, struct {
+			Error  string       `json:"error"`
+			Health sourceHealth `json:"health"`
+		}{
+			Error:  err.Error(),
+			Health: newSourceHealth(health),
+		})
+		return nil
+	}
+
+	writeJSON(w, http.StatusOK, newSourceHealth(health))
+	return nil
+}
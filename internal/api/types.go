@@ -0,0 +1,131 @@
+package api
+
+import (
+	"time"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+type source struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Priority  int       `json:"priority"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newSource(s *models.Source) source {
+	return source{
+		ID:        s.ID,
+		Name:      s.Name,
+		URL:       s.URL,
+		Priority:  s.Priority,
+		CreatedAt: s.CreatedDate.UTC(),
+	}
+}
+
+type sourceHealth struct {
+	SourceID            int64      `json:"source_id"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Items               int        `json:"items"`
+}
+
+func newSourceHealth(h models.SourceHealth) sourceHealth {
+	return sourceHealth{
+		SourceID:            h.SourceID,
+		LastSuccess:         timePtr(h.LastSuccessDate),
+		LastFailure:         timePtr(h.LastFailureDate),
+		LastError:           h.LastError,
+		ConsecutiveFailures: h.ConsecutiveFailures,
+		Items:               h.LastItems,
+	}
+}
+
+type article struct {
+	ID               int64      `json:"id"`
+	SourceID         int64      `json:"source_id"`
+	Title            string     `json:"title"`
+	Link             string     `json:"link"`
+	Summary          string     `json:"summary"`
+	PostSummary      string     `json:"post_summary,omitempty"`
+	Tags             []string   `json:"tags"`
+	Author           string     `json:"author,omitempty"`
+	ImageURL         string     `json:"image_url,omitempty"`
+	ModerationStatus string     `json:"moderation_status"`
+	Score            float64    `json:"score"`
+	PublishedAt      time.Time  `json:"published_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	PostedAt         *time.Time `json:"posted_at,omitempty"`
+	EditedAt         *time.Time `json:"edited_at,omitempty"`
+	RetractedAt      *time.Time `json:"retracted_at,omitempty"`
+}
+
+func newArticle(a *models.Article) article {
+	tags := a.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return article{
+		ID:               a.ID,
+		SourceID:         a.SourceID,
+		Title:            a.Title,
+		Link:             a.Link,
+		Summary:          a.Summary,
+		PostSummary:      a.PostSummary,
+		Tags:             tags,
+		Author:           a.Author,
+		ImageURL:         a.ImageURL,
+		ModerationStatus: a.ModerationStatus,
+		Score:            a.Score,
+		PublishedAt:      a.PublishedDate.UTC(),
+		CreatedAt:        a.CreatedDate.UTC(),
+		PostedAt:         timePtr(a.PostedDate),
+		EditedAt:         timePtr(a.EditedDate),
+		RetractedAt:      timePtr(a.RetractedDate),
+	}
+}
+
+type delivery struct {
+	ID          int64      `json:"id"`
+	ArticleID   int64      `json:"article_id"`
+	Destination string     `json:"destination"`
+	ChatID      int64      `json:"chat_id,omitempty"`
+	MessageID   int64      `json:"message_id,omitempty"`
+	URL         string     `json:"url,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+func newDelivery(d *models.Delivery) delivery {
+	return delivery{
+		ID:          d.ID,
+		ArticleID:   d.ArticleID,
+		Destination: d.Destination,
+		ChatID:      d.ChatID,
+		MessageID:   d.MessageID,
+		URL:         d.URL,
+		CreatedAt:   d.CreatedDate.UTC(),
+		DeletedAt:   timePtr(d.DeletedDate),
+	}
+}
+
+func mapSlice[T, R any](items []T, f func(T) R) []R {
+	result := make([]R, 0, len(items))
+	for _, v := range items {
+		result = append(result, f(v))
+	}
+	return result
+}
+
+// timePtr omits the zero time from the JSON responses.
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
@@ -0,0 +1,47 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+)
+
+func ViewCallbackListSources(lister SourceLister, qualities SourceQualityProvider) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		data, err := botkit.ParseCallbackData(update.CallbackQuery.Data)
+		if err != nil {
+			return fmt.Errorf("parse callback data: %w", err)
+		}
+		number, err := data.IntArg(0)
+		if err != nil {
+			return fmt.Errorf("parse page number: %w", err)
+		}
+
+		sources, err := lister.Sources(ctx)
+		if err != nil {
+			return fmt.Errorf("list sources: %w", err)
+		}
+
+		quality, err := qualities.SourceQuality(ctx)
+		if err != nil {
+			return fmt.Errorf("get source quality: %w", err)
+		}
+
+		msgText, keyboard, hasKeyboard := renderSourcesPage(sources, quality, int(number))
+
+		message := update.CallbackQuery.Message
+		edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, msgText)
+		edit.ParseMode = parseModeMarkdownV2
+		if hasKeyboard {
+			edit.ReplyMarkup = &keyboard
+		}
+
+		if _, err := bot.Request(edit); err != nil {
+			return fmt.Errorf("edit message: %w", err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,179 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/notifier"
+)
+
+const (
+	ConversationEditSummaryName = "edit_summary"
+
+	keyArticleID = "article_id"
+	keySummary   = "summary"
+
+	summaryMaxSize = 3000
+)
+
+type ModerationStorage interface {
+	Moderate(ctx context.Context, id int64, status string, moderatorID int64) error
+	SetPostSummary(ctx context.Context, id int64, summary string) error
+}
+
+type ArticlePoster interface {
+	ApproveAndPost(ctx context.Context, id int64, moderatorID int64) error
+	SendToModeration(ctx context.Context, id int64) error
+}
+
+type ConversationStarter interface {
+	StartConversation(ctx context.Context, chatID int64, name string, answers map[string]string) error
+}
+
+func ViewCallbackModeration(
+	storage ModerationStorage,
+	poster ArticlePoster,
+	conversations ConversationStarter,
+) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		data, err := botkit.ParseCallbackData(update.CallbackQuery.Data)
+		if err != nil {
+			return fmt.Errorf("parse callback data: %w", err)
+		}
+		articleID, err := data.IntArg(1)
+		if err != nil {
+			return fmt.Errorf("parse article ID: %w", err)
+		}
+
+		var (
+			moderator = update.CallbackQuery.From
+			message   = update.CallbackQuery.Message
+			status    string
+		)
+
+		switch data.Arg(0) {
+		case notifier.ModerationActionApprove:
+			if err := storage.Moderate(ctx, articleID, models.ModerationStatusApproved, moderator.ID); err != nil {
+				return fmt.Errorf("approve article: %w", err)
+			}
+			status = "✅ Approved by " + userName(moderator)
+		case notifier.ModerationActionReject:
+			if err := storage.Moderate(ctx, articleID, models.ModerationStatusRejected, moderator.ID); err != nil {
+				return fmt.Errorf("reject article: %w", err)
+			}
+			status = "❌ Rejected by " + userName(moderator)
+		case notifier.ModerationActionPost:
+			if err := poster.ApproveAndPost(ctx, articleID, moderator.ID); err != nil && !errors.Is(err, notifier.ErrAlreadyPosted) {
+				return fmt.Errorf("post article: %w", err)
+			}
+			status = "🚀 Posted by " + userName(moderator)
+		case notifier.ModerationActionEdit:
+			// the summary is asked in the private chat, where the answer can't
+			// come from another member of the moderators' chat
+			err := conversations.StartConversation(ctx, moderator.ID, ConversationEditSummaryName, map[string]string{
+				keyArticleID: strconv.FormatInt(articleID, 10),
+			})
+			if botkit.IsChatUnavailableError(err) {
+				botkit.SetCallbackAnswer(ctx, "Start a private chat with @"+bot.Self.UserName+" first", true)
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("start conversation: %w", err)
+			}
+			botkit.SetCallbackAnswer(ctx, "Send the new summary to the bot in the private chat", false)
+			return nil
+		default:
+			return fmt.Errorf("unknown moderation action %q", data.Arg(0))
+		}
+
+		edit := tgbotapi.NewEditMessageReplyMarkup(message.Chat.ID, message.MessageID, tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(botkit.NoopButton(status)),
+		))
+		if _, err := bot.Request(edit); err != nil {
+			return fmt.Errorf("edit message: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func ViewCmdEditSummary(conversations ConversationStarter) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		id := strings.TrimSpace(update.Message.CommandArguments())
+		if err := validateID(id); err != nil {
+			return sendText(bot, update.Message.Chat.ID, "Usage: /edit_summary [article ID]")
+		}
+
+		// the summary is asked in the private chat, like from the moderation
+		// buttons
+		err := conversations.StartConversation(ctx, update.Message.From.ID, ConversationEditSummaryName, map[string]string{
+			keyArticleID: id,
+		})
+		if botkit.IsChatUnavailableError(err) {
+			return sendText(bot, update.Message.Chat.ID, "Start a private chat with @"+bot.Self.UserName+" first.")
+		}
+		return err
+	}
+}
+
+func ConversationEditSummary(storage ModerationStorage, poster ArticlePoster) botkit.Conversation {
+	return botkit.Conversation{
+		Steps: []botkit.Step{
+			{Key: keyArticleID, Prompt: "Send me the article ID.", Validate: validateID},
+			{Key: keySummary, Prompt: "Send me the new summary of the article.", Validate: validateSummary},
+		},
+		Complete: func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update, answers map[string]string) error {
+			articleID, err := strconv.ParseInt(strings.TrimSpace(answers[keyArticleID]), 10, 64)
+			if err != nil {
+				return fmt.Errorf("parse article ID: %w", err)
+			}
+
+			if err := storage.SetPostSummary(ctx, articleID, strings.TrimSpace(answers[keySummary])); err != nil {
+				return fmt.Errorf("set summary: %w", err)
+			}
+
+			err = poster.SendToModeration(ctx, articleID)
+			switch {
+			case errors.Is(err, notifier.ErrAlreadyPosted):
+				return sendText(bot, update.Message.Chat.ID, "Summary updated, but the article is already posted.")
+			case errors.Is(err, notifier.ErrModerationDisabled):
+				return sendText(bot, update.Message.Chat.ID, "Summary updated.")
+			case err != nil:
+				return fmt.Errorf("send to moderation: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+func userName(user *tgbotapi.User) string {
+	if user.UserName != "" {
+		return "@" + user.UserName
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
+
+func validateID(text string) error {
+	if _, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64); err != nil {
+		return errors.New("The ID must be a number.")
+	}
+	return nil
+}
+
+func validateSummary(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return errors.New("The summary can't be empty.")
+	}
+	if len([]rune(text)) > summaryMaxSize {
+		return fmt.Errorf("The summary must be at most %d characters long.", summaryMaxSize)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/notifier"
+)
+
+type VoteStorage interface {
+	Vote(ctx context.Context, articleID, userID int64, value int) (models.Votes, error)
+}
+
+func ViewCallbackVote(storage VoteStorage) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		data, err := botkit.ParseCallbackData(update.CallbackQuery.Data)
+		if err != nil {
+			return fmt.Errorf("parse callback data: %w", err)
+		}
+		articleID, err := data.IntArg(0)
+		if err != nil {
+			return fmt.Errorf("parse article ID: %w", err)
+		}
+
+		var value int
+		switch data.Arg(1) {
+		case notifier.VoteUp:
+			value = 1
+		case notifier.VoteDown:
+			value = -1
+		default:
+			return fmt.Errorf("unknown vote %q", data.Arg(1))
+		}
+
+		votes, err := storage.Vote(ctx, articleID, update.CallbackQuery.From.ID, value)
+		if err != nil {
+			return fmt.Errorf("vote: %w", err)
+		}
+
+		message := update.CallbackQuery.Message
+		edit := tgbotapi.NewEditMessageReplyMarkup(
+			message.Chat.ID,
+			message.MessageID,
+			notifier.UpdateFeedbackKeyboard(message.ReplyMarkup, articleID, votes),
+		)
+		if _, err := bot.Request(edit); err != nil {
+			return fmt.Errorf("edit message: %w", err)
+		}
+
+		botkit.SetCallbackAnswer(ctx, "Thanks for your feedback!", false)
+		return nil
+	}
+}
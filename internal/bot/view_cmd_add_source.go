@@ -2,10 +2,15 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/botkit/markup"
 	"github.com/to77e/news-fetching-bot/internal/models"
 )
 
@@ -29,28 +34,92 @@ func ViewCmdAddSource(storage SourceRepository) botkit.ViewFunc {
 		}
 
 		source := models.Source{
-			Name: args.Name,
-			URL:  args.URL,
+			Name:     args.Name,
+			URL:      args.URL,
+			Priority: args.Priority,
 		}
 
-		sourceID, err := storage.Add(ctx, source)
-		if err != nil {
-			return fmt.Errorf("add source: %w", err)
-		}
+		return addSource(ctx, bot, update, storage, source)
+	}
+}
 
-		var (
-			msgText = fmt.Sprintf(
-				"Source added with ID: `%d`\\. Use this ID for updating the source or deleting it\\.",
-				sourceID,
-			)
-			reply = tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
-		)
-		reply.ParseMode = parseModeMarkdownV2
-
-		if _, err := bot.Send(reply); err != nil {
-			return fmt.Errorf("send message: %w", err)
-		}
+func ConversationAddSource(storage SourceRepository) botkit.Conversation {
+	const (
+		keyURL      = "url"
+		keyName     = "name"
+		keyPriority = "priority"
+	)
+
+	return botkit.Conversation{
+		Steps: []botkit.Step{
+			{Key: keyURL, Prompt: "Send me the feed URL.", Validate: validateFeedURL},
+			{Key: keyName, Prompt: "What is the name of the source?", Validate: validateSourceName},
+			{Key: keyPriority, Prompt: "What is the priority of the source? Send a number from 0 to 100.", Validate: validatePriority},
+		},
+		Complete: func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update, answers map[string]string) error {
+			priority, err := strconv.Atoi(strings.TrimSpace(answers[keyPriority]))
+			if err != nil {
+				return fmt.Errorf("parse priority: %w", err)
+			}
+
+			source := models.Source{
+				Name:     strings.TrimSpace(answers[keyName]),
+				URL:      strings.TrimSpace(answers[keyURL]),
+				Priority: priority,
+			}
+
+			return addSource(ctx, bot, update, storage, source)
+		},
+	}
+}
+
+func addSource(
+	ctx context.Context,
+	bot *tgbotapi.BotAPI,
+	update tgbotapi.Update,
+	storage SourceRepository,
+	source models.Source,
+) error {
+	sourceID, err := storage.Add(ctx, source)
+	if err != nil {
+		return fmt.Errorf("add source: %w", err)
+	}
+
+	var (
+		msgText = markup.NewBuilder().
+			Text("Source added with ID: ").Code(strconv.FormatInt(sourceID, 10)).
+			Text(". Use this ID for updating the source or deleting it.").
+			MarkdownV2()
+		reply = tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
+	)
+	reply.ParseMode = parseModeMarkdownV2
+
+	if _, err := bot.Send(reply); err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+
+	return nil
+}
+
+func validateFeedURL(text string) error {
+	u, err := url.Parse(strings.TrimSpace(text))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("This doesn't look like a feed URL, it must start with http:// or https://.")
+	}
+	return nil
+}
+
+func validateSourceName(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return errors.New("The name can't be empty.")
+	}
+	return nil
+}
 
-		return nil
+func validatePriority(text string) error {
+	priority, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || priority < 0 || priority > 100 {
+		return errors.New("The priority must be a number from 0 to 100.")
 	}
+	return nil
 }
@@ -0,0 +1,130 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/botkit/markup"
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/repository"
+)
+
+const (
+	articleDateFmt        = "2006-01-02 15:04"
+	articleSummaryMaxSize = 1000
+)
+
+var htmlTags = regexp.MustCompile(`<[^>]*>`)
+
+type ArticleProvider interface {
+	ArticleByID(ctx context.Context, id int64) (*models.Article, error)
+}
+
+type DeliveryProvider interface {
+	DeliveriesByArticle(ctx context.Context, articleID int64) ([]*models.Delivery, error)
+}
+
+func ViewCmdArticle(articles ArticleProvider, sources SourceProvider, deliveries DeliveryProvider) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		id, err := strconv.ParseInt(strings.TrimSpace(update.Message.CommandArguments()), 10, 64)
+		if err != nil {
+			return sendText(bot, update.Message.Chat.ID, "Usage: /article <article ID>")
+		}
+
+		article, err := articles.ArticleByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrorArticleNotFound) {
+				return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Article %d not found.", id))
+			}
+			return fmt.Errorf("get article: %w", err)
+		}
+
+		source, err := sources.SourceByID(ctx, article.SourceID)
+		if err != nil {
+			return fmt.Errorf("get source: %w", err)
+		}
+
+		articleDeliveries, err := deliveries.DeliveriesByArticle(ctx, article.ID)
+		if err != nil {
+			return fmt.Errorf("list deliveries: %w", err)
+		}
+
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, formatArticle(article, source, articleDeliveries))
+		reply.ParseMode = parseModeMarkdownV2
+		reply.DisableWebPagePreview = true
+
+		if _, err := bot.Send(reply); err != nil {
+			return fmt.Errorf("send message: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func formatArticle(article *models.Article, source *models.Source, deliveries []*models.Delivery) string {
+	message := markup.NewBuilder().
+		Bold(article.Title).Text("\n\n").
+		Text("ID: ").Code(strconv.FormatInt(article.ID, 10)).Text("\n").
+		Textf("Source: %s (ID ", source.Name).Code(strconv.FormatInt(source.ID, 10)).Text(")\n").
+		Textf("Published: %s\n", formatDate(article.PublishedDate)).
+		Textf("Fetched: %s\n", formatDate(article.CreatedDate)).
+		Textf("Posted: %s\n", formatDate(article.PostedDate))
+	if !article.EditedDate.IsZero() {
+		message.Textf("Edited: %s\n", formatDate(article.EditedDate))
+	}
+	if !article.RetractedDate.IsZero() {
+		message.Textf("Retracted: %s\n", formatDate(article.RetractedDate))
+	}
+	if len(article.Tags) > 0 {
+		message.Textf("Tags: %s\n", strings.Join(article.Tags, ", "))
+	}
+	message.Textf("Link: %s\n", article.Link)
+
+	if summary := plainSummary(article.Summary); summary != "" {
+		message.Textf("\n%s\n", summary)
+	}
+
+	if len(deliveries) > 0 {
+		message.Text("\nDeliveries:\n")
+		for _, v := range deliveries {
+			line := fmt.Sprintf("%s, %s", v.Destination, formatDate(v.CreatedDate))
+			if !v.DeletedDate.IsZero() {
+				line += ", deleted " + formatDate(v.DeletedDate)
+			}
+			message.Text("• ")
+			if v.URL != "" {
+				message.Link(line, v.URL)
+			} else {
+				message.Text(line)
+			}
+			message.Text("\n")
+		}
+	}
+
+	return message.MarkdownV2()
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	return t.Format(articleDateFmt)
+}
+
+func plainSummary(summary string) string {
+	text := strings.TrimSpace(html.UnescapeString(htmlTags.ReplaceAllString(summary, " ")))
+	text = strings.Join(strings.Fields(text), " ")
+
+	if runes := []rune(text); len(runes) > articleSummaryMaxSize {
+		text = string(runes[:articleSummaryMaxSize]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,26 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+func ViewCmdDelivery(storage SubscriptionStorage) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		frequency := strings.TrimSpace(update.Message.CommandArguments())
+		if frequency != models.DeliveryFrequencyInstant && frequency != models.DeliveryFrequencyDigest {
+			return sendText(bot, update.Message.Chat.ID, "Usage: /delivery instant|digest")
+		}
+
+		if err := storage.SetFrequency(ctx, update.SentFrom().ID, frequency); err != nil {
+			return fmt.Errorf("set frequency: %w", err)
+		}
+
+		return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Delivery changed to %s.", frequency))
+	}
+}
@@ -0,0 +1,192 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/botkit/markup"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	CallbackLatest        = "latest"
+	CallbackLatestVersion = 1
+
+	latestPageSize = 10
+
+	markerPosted = "✅"
+	markerQueued = "🕓"
+)
+
+// statusMarkers mark the articles in the list by their status.
+var statusMarkers = []struct {
+	status string
+	marker string
+}{
+	{models.ArticleStatusPosted, markerPosted},
+	{models.ArticleStatusQueued, markerQueued},
+	{models.ModerationStatusPending, "👀"},
+	{models.ModerationStatusApproved, "👍"},
+	{models.ModerationStatusRejected, "⛔"},
+	{models.ArticleStatusRetracted, "🗑"},
+}
+
+func statusMarker(status string) string {
+	for _, v := range statusMarkers {
+		if v.status == status {
+			return v.marker
+		}
+	}
+	return markerQueued
+}
+
+type LatestArticleProvider interface {
+	Latest(ctx context.Context, q models.ArticleQuery) ([]*models.Article, int, error)
+}
+
+func ViewCmdLatest(articles LatestArticleProvider, sources SourceLister) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		sourceList, err := sources.Sources(ctx)
+		if err != nil {
+			return fmt.Errorf("list sources: %w", err)
+		}
+
+		var sourceID int64
+		if arg := strings.TrimSpace(update.Message.CommandArguments()); arg != "" {
+			source := findSource(sourceList, arg)
+			if source == nil {
+				return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Source %q not found. Usage: /latest [source ID or name]", arg))
+			}
+			sourceID = source.ID
+		}
+
+		msgText, keyboard, hasKeyboard, err := renderLatestPage(ctx, articles, sourceList, sourceID, 0)
+		if err != nil {
+			return err
+		}
+
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
+		reply.ParseMode = parseModeMarkdownV2
+		reply.DisableWebPagePreview = true
+		if hasKeyboard {
+			reply.ReplyMarkup = keyboard
+		}
+
+		if _, err := bot.Send(reply); err != nil {
+			return fmt.Errorf("send message: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func ViewCallbackLatest(articles LatestArticleProvider, sources SourceLister) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		data, err := botkit.ParseCallbackData(update.CallbackQuery.Data)
+		if err != nil {
+			return fmt.Errorf("parse callback data: %w", err)
+		}
+		number, err := data.IntArg(0)
+		if err != nil {
+			return fmt.Errorf("parse page number: %w", err)
+		}
+		sourceID, err := data.IntArg(1)
+		if err != nil {
+			return fmt.Errorf("parse source ID: %w", err)
+		}
+
+		sourceList, err := sources.Sources(ctx)
+		if err != nil {
+			return fmt.Errorf("list sources: %w", err)
+		}
+
+		msgText, keyboard, hasKeyboard, err := renderLatestPage(ctx, articles, sourceList, sourceID, int(number))
+		if err != nil {
+			return err
+		}
+
+		message := update.CallbackQuery.Message
+		edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, msgText)
+		edit.ParseMode = parseModeMarkdownV2
+		edit.DisableWebPagePreview = true
+		if hasKeyboard {
+			edit.ReplyMarkup = &keyboard
+		}
+
+		if _, err := bot.Request(edit); err != nil {
+			return fmt.Errorf("edit message: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func renderLatestPage(
+	ctx context.Context,
+	articles LatestArticleProvider,
+	sources []*models.Source,
+	sourceID int64,
+	number int,
+) (string, tgbotapi.InlineKeyboardMarkup, bool, error) {
+	query := models.ArticleQuery{
+		SourceID: sourceID,
+		Limit:    latestPageSize,
+		Offset:   uint64(max(number, 0) * latestPageSize),
+	}
+	latest, total, err := articles.Latest(ctx, query)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, false, fmt.Errorf("list latest articles: %w", err)
+	}
+
+	if total == 0 {
+		return markup.NewBuilder().Text("No articles yet.").MarkdownV2(), tgbotapi.InlineKeyboardMarkup{}, false, nil
+	}
+
+	// a stale button may point past the end of the list, the last page is
+	// shown then
+	page := botkit.NewPage(number, latestPageSize, total)
+	if offset := uint64(page.Offset()); offset != query.Offset {
+		query.Offset = offset
+		if latest, total, err = articles.Latest(ctx, query); err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, false, fmt.Errorf("list latest articles: %w", err)
+		}
+		page = botkit.NewPage(page.Number, latestPageSize, total)
+	}
+
+	message := markup.NewBuilder().Textf("Latest articles (total %d):\n\n", total)
+	for _, v := range latest {
+		message.
+			Text(statusMarker(v.Status())+" ").Code(strconv.FormatInt(v.ID, 10)).Text(" ").Link(v.Title, v.Link).
+			Textf("\n%s · %s\n\n", sourceName(sources, v.SourceID), v.PublishedDate.Format(articleDateFmt))
+	}
+	for _, v := range statusMarkers {
+		message.Textf("%s %s ", v.marker, v.status)
+	}
+	message.Text("· /article ID for details")
+
+	keyboard, ok := botkit.PaginationKeyboard(CallbackLatest, CallbackLatestVersion, page, strconv.FormatInt(sourceID, 10))
+	return message.MarkdownV2(), keyboard, ok, nil
+}
+
+func findSource(sources []*models.Source, arg string) *models.Source {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	for _, v := range sources {
+		if (err == nil && v.ID == id) || strings.EqualFold(v.Name, arg) {
+			return v
+		}
+	}
+	return nil
+}
+
+func sourceName(sources []*models.Source, id int64) string {
+	for _, v := range sources {
+		if v.ID == id {
+			return v.Name
+		}
+	}
+	return strconv.FormatInt(id, 10)
+}
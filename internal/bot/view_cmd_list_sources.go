@@ -3,38 +3,48 @@ package bot
 import (
 	"context"
 	"fmt"
-	"github.com/to77e/news-fetching-bot/internal/botkit/markup"
-	"strings"
+	"strconv"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/botkit/markup"
 	"github.com/to77e/news-fetching-bot/internal/models"
 )
 
+const (
+	CallbackListSources        = "sources"
+	CallbackListSourcesVersion = 1
+
+	sourcesPageSize = 10
+)
+
 type SourceLister interface {
 	Sources(ctx context.Context) ([]*models.Source, error)
 }
 
-func ViewCmdListSources(lister SourceLister) botkit.ViewFunc {
+type SourceQualityProvider interface {
+	SourceQuality(ctx context.Context) (map[int64]models.SourceQuality, error)
+}
+
+func ViewCmdListSources(lister SourceLister, qualities SourceQualityProvider) botkit.ViewFunc {
 	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
 		sources, err := lister.Sources(ctx)
 		if err != nil {
 			return fmt.Errorf("list sources: %w", err)
 		}
 
-		var sourceInfos []string
-		for _, v := range sources {
-			src := formatSource(v)
-			sourceInfos = append(sourceInfos, src)
+		quality, err := qualities.SourceQuality(ctx)
+		if err != nil {
+			return fmt.Errorf("get source quality: %w", err)
 		}
-		msgText := fmt.Sprintf(
-			"List sources \\(total %d\\):\n\n%s",
-			len(sources),
-			strings.Join(sourceInfos, "\n\n"),
-		)
+
+		msgText, keyboard, hasKeyboard := renderSourcesPage(sources, quality, 0)
 
 		reply := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
 		reply.ParseMode = parseModeMarkdownV2
+		if hasKeyboard {
+			reply.ReplyMarkup = keyboard
+		}
 
 		if _, err := bot.Send(reply); err != nil {
 			return fmt.Errorf("send message: %w", err)
@@ -44,11 +54,31 @@ func ViewCmdListSources(lister SourceLister) botkit.ViewFunc {
 	}
 }
 
-func formatSource(source *models.Source) string {
-	return fmt.Sprintf(
-		"*%s*\nID: `%d`\nfeed URL: %s",
-		markup.EscapeForMarkdown(source.Name),
-		source.ID,
-		markup.EscapeForMarkdown(source.URL),
-	)
+func renderSourcesPage(
+	sources []*models.Source,
+	quality map[int64]models.SourceQuality,
+	number int,
+) (string, tgbotapi.InlineKeyboardMarkup, bool) {
+	page := botkit.NewPage(number, sourcesPageSize, len(sources))
+	start, end := page.Bounds()
+
+	message := markup.NewBuilder().Textf("List sources (total %d):", len(sources))
+	for _, v := range sources[start:end] {
+		message.Text("\n\n").Append(formatSource(v)...)
+		if q, ok := quality[v.ID]; ok {
+			message.Textf("\nquality: %.2f (👍 %d / 👎 %d)", q.Score, q.Votes.Up, q.Votes.Down)
+		}
+	}
+
+	keyboard, ok := botkit.PaginationKeyboard(CallbackListSources, CallbackListSourcesVersion, page)
+	return message.MarkdownV2(), keyboard, ok
+}
+
+func formatSource(source *models.Source) []markup.Node {
+	return []markup.Node{
+		markup.Bold(markup.Text(source.Name)),
+		markup.Text("\nID: "),
+		markup.Code(strconv.FormatInt(source.ID, 10)),
+		markup.Textf("\nfeed URL: %s", source.URL),
+	}
 }
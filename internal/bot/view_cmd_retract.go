@@ -0,0 +1,42 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/notifier"
+	"github.com/to77e/news-fetching-bot/internal/repository"
+)
+
+type ArticleRetractor interface {
+	RetractArticle(ctx context.Context, id int64) (int, error)
+}
+
+func ViewCmdRetract(retractor ArticleRetractor) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		id, err := strconv.ParseInt(strings.TrimSpace(update.Message.CommandArguments()), 10, 64)
+		if err != nil {
+			return sendText(bot, update.Message.Chat.ID, "Usage: /retract <article ID>")
+		}
+
+		deleted, err := retractor.RetractArticle(ctx, id)
+		switch {
+		case errors.Is(err, repository.ErrorArticleNotFound):
+			return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Article %d not found.", id))
+		case errors.Is(err, notifier.ErrAlreadyRetracted):
+			return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Article %d is already retracted.", id))
+		case err != nil:
+			return fmt.Errorf("retract article: %w", err)
+		}
+
+		if deleted == 0 {
+			return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Article %d is retracted and won't be posted.", id))
+		}
+		return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Article %d is retracted, %d message(s) deleted.", id, deleted))
+	}
+}
@@ -0,0 +1,233 @@
+package bot
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/botkit/markup"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	CallbackSearch        = "search"
+	CallbackSearchVersion = 1
+
+	searchPageSize  = 5
+	searchQueryTTL  = 24 * time.Hour
+	searchDateFmt   = "2006-01-02"
+	searchUsageText = "Usage: /search <query> [source:<source ID>] [from:YYYY-MM-DD] [to:YYYY-MM-DD]"
+)
+
+type ArticleSearcher interface {
+	Search(ctx context.Context, q models.ArticleQuery) ([]*models.Article, int, error)
+}
+
+// SearchQueries remembers the queries of sent search results, so the
+// pagination buttons only need to carry a short key of the query.
+type SearchQueries struct {
+	mu      sync.Mutex
+	queries map[string]searchQuery
+}
+
+type searchQuery struct {
+	query     models.ArticleQuery
+	expiresAt time.Time
+}
+
+func NewSearchQueries() *SearchQueries {
+	return &SearchQueries{
+		queries: make(map[string]searchQuery),
+	}
+}
+
+func (s *SearchQueries) put(chatID int64, args string, query models.ArticleQuery) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, v := range s.queries {
+		if v.expiresAt.Before(now) {
+			delete(s.queries, key)
+		}
+	}
+
+	hash := sha1.Sum([]byte(strconv.FormatInt(chatID, 10) + args))
+	key := hex.EncodeToString(hash[:8])
+	s.queries[key] = searchQuery{query: query, expiresAt: now.Add(searchQueryTTL)}
+
+	return key
+}
+
+func (s *SearchQueries) get(key string) (models.ArticleQuery, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.queries[key]
+	if !ok || v.expiresAt.Before(time.Now()) {
+		return models.ArticleQuery{}, false
+	}
+	return v.query, true
+}
+
+func ViewCmdSearch(searcher ArticleSearcher, queries *SearchQueries) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		args := strings.TrimSpace(update.Message.CommandArguments())
+
+		query, err := parseSearchArgs(args)
+		if err != nil {
+			return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("%s.\n\n%s", err.Error(), searchUsageText))
+		}
+
+		key := queries.put(update.Message.Chat.ID, args, query)
+
+		msgText, keyboard, hasKeyboard, err := renderSearchPage(ctx, searcher, query, key, 0)
+		if err != nil {
+			return err
+		}
+
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
+		reply.ParseMode = parseModeMarkdownV2
+		reply.DisableWebPagePreview = true
+		if hasKeyboard {
+			reply.ReplyMarkup = keyboard
+		}
+
+		if _, err := bot.Send(reply); err != nil {
+			return fmt.Errorf("send message: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func ViewCallbackSearch(searcher ArticleSearcher, queries *SearchQueries) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		data, err := botkit.ParseCallbackData(update.CallbackQuery.Data)
+		if err != nil {
+			return fmt.Errorf("parse callback data: %w", err)
+		}
+		number, err := data.IntArg(0)
+		if err != nil {
+			return fmt.Errorf("parse page number: %w", err)
+		}
+
+		key := data.Arg(1)
+		query, ok := queries.get(key)
+		if !ok {
+			botkit.SetCallbackAnswer(ctx, "The search has expired, please run /search again.", true)
+			return nil
+		}
+
+		msgText, keyboard, hasKeyboard, err := renderSearchPage(ctx, searcher, query, key, int(number))
+		if err != nil {
+			return err
+		}
+
+		message := update.CallbackQuery.Message
+		edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, msgText)
+		edit.ParseMode = parseModeMarkdownV2
+		edit.DisableWebPagePreview = true
+		if hasKeyboard {
+			edit.ReplyMarkup = &keyboard
+		}
+
+		if _, err := bot.Request(edit); err != nil {
+			return fmt.Errorf("edit message: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func parseSearchArgs(args string) (models.ArticleQuery, error) {
+	var (
+		query models.ArticleQuery
+		words []string
+		err   error
+	)
+
+	for _, field := range strings.Fields(args) {
+		name, value, found := strings.Cut(field, ":")
+		if !found {
+			words = append(words, field)
+			continue
+		}
+
+		switch name {
+		case "source":
+			if query.SourceID, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return query, fmt.Errorf("Invalid source ID %q", value)
+			}
+		case "from":
+			if query.From, err = time.Parse(searchDateFmt, value); err != nil {
+				return query, fmt.Errorf("Invalid date %q", value)
+			}
+		case "to":
+			if query.To, err = time.Parse(searchDateFmt, value); err != nil {
+				return query, fmt.Errorf("Invalid date %q", value)
+			}
+			// include the whole day
+			query.To = query.To.AddDate(0, 0, 1)
+		default:
+			words = append(words, field)
+		}
+	}
+
+	query.Text = strings.Join(words, " ")
+	if query.Text == "" {
+		return query, fmt.Errorf("The query is empty")
+	}
+
+	return query, nil
+}
+
+func renderSearchPage(
+	ctx context.Context,
+	searcher ArticleSearcher,
+	query models.ArticleQuery,
+	key string,
+	number int,
+) (string, tgbotapi.InlineKeyboardMarkup, bool, error) {
+	query.Limit = searchPageSize
+	query.Offset = uint64(max(number, 0) * searchPageSize)
+
+	articles, total, err := searcher.Search(ctx, query)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, false, fmt.Errorf("search articles: %w", err)
+	}
+
+	if total == 0 {
+		return markup.NewBuilder().Text("Nothing found for ").Bold(query.Text).Text(".").MarkdownV2(),
+			tgbotapi.InlineKeyboardMarkup{}, false, nil
+	}
+
+	// a stale button may point past the end of the results, which shrank
+	// meanwhile, the last page is shown then
+	page := botkit.NewPage(number, searchPageSize, total)
+	if offset := uint64(page.Offset()); offset != query.Offset {
+		query.Offset = offset
+		if articles, total, err = searcher.Search(ctx, query); err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, false, fmt.Errorf("search articles: %w", err)
+		}
+		page = botkit.NewPage(page.Number, searchPageSize, total)
+	}
+
+	message := markup.NewBuilder().Text("Search results for ").Bold(query.Text).Textf(" (total %d):", total)
+	for i, v := range articles {
+		message.
+			Textf("\n\n%d. ", page.Offset()+i+1).Bold(v.Title).
+			Textf("\n%s · ID ", v.PublishedDate.Format(searchDateFmt)).Code(strconv.FormatInt(v.ID, 10)).
+			Textf("\n%s", v.Link)
+	}
+
+	keyboard, ok := botkit.PaginationKeyboard(CallbackSearch, CallbackSearchVersion, page, key)
+	return message.MarkdownV2(), keyboard, ok, nil
+}
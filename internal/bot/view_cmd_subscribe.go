@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/repository"
+)
+
+const subscribeUsage = "Usage: /subscribe source <source ID> | tag <tag> | query <keywords>"
+
+type SubscriptionStorage interface {
+	Subscribe(ctx context.Context, subscription models.Subscription) (int64, error)
+	Subscriptions(ctx context.Context, chatID int64) ([]*models.Subscription, error)
+	Unsubscribe(ctx context.Context, chatID, id int64) error
+	Subscriber(ctx context.Context, chatID int64) (*models.Subscriber, error)
+	SetFrequency(ctx context.Context, chatID int64, frequency string) error
+}
+
+type SourceProvider interface {
+	SourceByID(ctx context.Context, id int64) (*models.Source, error)
+}
+
+func ViewCmdSubscribe(storage SubscriptionStorage, sources SourceProvider) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		kind, value, _ := strings.Cut(strings.TrimSpace(update.Message.CommandArguments()), " ")
+		value = strings.TrimSpace(value)
+
+		if value == "" {
+			return sendText(bot, update.Message.Chat.ID, subscribeUsage)
+		}
+
+		switch kind {
+		case models.SubscriptionKindSource:
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return sendText(bot, update.Message.Chat.ID, subscribeUsage)
+			}
+			if _, err := sources.SourceByID(ctx, id); err != nil {
+				if errors.Is(err, repository.ErrorSourceNotFound) {
+					return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Source %d not found.", id))
+				}
+				return fmt.Errorf("get source: %w", err)
+			}
+		case models.SubscriptionKindTag, models.SubscriptionKindQuery:
+			value = strings.ToLower(value)
+		default:
+			return sendText(bot, update.Message.Chat.ID, subscribeUsage)
+		}
+
+		// articles are delivered to the private chat with the subscriber
+		chatID := update.SentFrom().ID
+		id, err := storage.Subscribe(ctx, models.Subscription{
+			ChatID: chatID,
+			Kind:   kind,
+			Value:  value,
+		})
+		if err != nil {
+			return fmt.Errorf("subscribe: %w", err)
+		}
+
+		return sendText(bot, update.Message.Chat.ID, fmt.Sprintf(
+			"Subscribed to %s %q, subscription ID: %d. Matching articles will be sent to you by direct message.",
+			kind, value, id,
+		))
+	}
+}
+
+func sendText(bot *tgbotapi.BotAPI, chatID int64, text string) error {
+	if _, err := bot.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+)
+
+func ViewCmdSubscriptions(storage SubscriptionStorage) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		chatID := update.SentFrom().ID
+
+		subscriptions, err := storage.Subscriptions(ctx, chatID)
+		if err != nil {
+			return fmt.Errorf("list subscriptions: %w", err)
+		}
+		if len(subscriptions) == 0 {
+			return sendText(bot, update.Message.Chat.ID, "You have no subscriptions. "+subscribeUsage)
+		}
+
+		subscriber, err := storage.Subscriber(ctx, chatID)
+		if err != nil {
+			return fmt.Errorf("get subscriber: %w", err)
+		}
+
+		var message strings.Builder
+		message.WriteString(fmt.Sprintf("Your subscriptions (delivery: %s):\n\n", subscriber.Frequency))
+		for _, v := range subscriptions {
+			message.WriteString(fmt.Sprintf("%d. %s %q\n", v.ID, v.Kind, v.Value))
+		}
+		message.WriteString("\nUse /unsubscribe <ID> to remove a subscription and /delivery instant|digest to change the delivery.")
+
+		return sendText(bot, update.Message.Chat.ID, message.String())
+	}
+}
@@ -0,0 +1,189 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/notifier"
+	"github.com/to77e/news-fetching-bot/internal/repository"
+)
+
+const (
+	setTemplateUsage = "Usage: /set_template channel|source <source ID> [markdown|html]\n" +
+		"<template>\n\n" +
+		"The template is a Go text/template with the fields .Title, .Summary, .Link, .Source, .Tags, " +
+		".Author, .ImageURL and .Published and the functions md, mdurl, html, join, date and truncate. " +
+		"Send no template to reset to the default one."
+	previewTemplateUsage = "Usage: /preview_template channel|source <source ID> [markdown|html]\n[template]"
+)
+
+type TemplateStorage interface {
+	SetTemplate(ctx context.Context, template models.PostTemplate) error
+	Template(ctx context.Context, scope string, targetID int64) (*models.PostTemplate, error)
+	DeleteTemplate(ctx context.Context, scope string, targetID int64) error
+}
+
+// templateArgs are the arguments of the template commands: a header line with
+// the scope, the source ID and the parse mode followed by the template body.
+type templateArgs struct {
+	scope     string
+	targetID  int64
+	parseMode string
+	body      string
+}
+
+func ViewCmdSetTemplate(storage TemplateStorage, sources SourceProvider, channelID int64) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		args, err := parseTemplateArgs(update.Message.CommandArguments(), channelID)
+		if err != nil {
+			return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("%s.\n\n%s", err.Error(), setTemplateUsage))
+		}
+
+		exists, err := templateTargetExists(ctx, sources, args)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Source %d not found.", args.targetID))
+		}
+
+		if args.body == "" {
+			err := storage.DeleteTemplate(ctx, args.scope, args.targetID)
+			if err != nil && !errors.Is(err, repository.ErrorTemplateNotFound) {
+				return fmt.Errorf("delete template: %w", err)
+			}
+			return sendText(bot, update.Message.Chat.ID, "The template is reset to the default one.")
+		}
+
+		if _, err := notifier.ParseTemplate(args.parseMode, args.body); err != nil {
+			return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("The template is not saved: %s.", err.Error()))
+		}
+
+		if err := storage.SetTemplate(ctx, models.PostTemplate{
+			Scope:     args.scope,
+			TargetID:  args.targetID,
+			ParseMode: args.parseMode,
+			Body:      args.body,
+		}); err != nil {
+			return fmt.Errorf("save template: %w", err)
+		}
+
+		return sendText(bot, update.Message.Chat.ID, "The template is saved. Use /preview_template to see how posts look.")
+	}
+}
+
+func ViewCmdPreviewTemplate(storage TemplateStorage, sources SourceProvider, channelID int64) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		args, err := parseTemplateArgs(update.Message.CommandArguments(), channelID)
+		if err != nil {
+			return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("%s.\n\n%s", err.Error(), previewTemplateUsage))
+		}
+
+		data := notifier.SamplePost
+		if args.scope == models.TemplateScopeSource {
+			source, err := sources.SourceByID(ctx, args.targetID)
+			if err != nil {
+				if errors.Is(err, repository.ErrorSourceNotFound) {
+					return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Source %d not found.", args.targetID))
+				}
+				return fmt.Errorf("get source: %w", err)
+			}
+			data.Source = source.Name
+		}
+
+		if args.body == "" {
+			postTemplate, err := storage.Template(ctx, args.scope, args.targetID)
+			switch {
+			case err != nil:
+				return fmt.Errorf("get template: %w", err)
+			case postTemplate == nil:
+				args.parseMode, args.body = tgbotapi.ModeMarkdownV2, notifier.DefaultTemplate
+			default:
+				args.parseMode, args.body = postTemplate.ParseMode, postTemplate.Body
+			}
+		}
+
+		tmpl, err := notifier.ParseTemplate(args.parseMode, args.body)
+		if err != nil {
+			return sendText(bot, update.Message.Chat.ID, err.Error()+".")
+		}
+
+		text, err := notifier.RenderTemplate(tmpl, data)
+		if err != nil {
+			return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("%s: %s.", notifier.ErrInvalidTemplate, err.Error()))
+		}
+
+		preview := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+		preview.ParseMode = args.parseMode
+		if _, err := bot.Send(preview); err != nil {
+			return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Telegram rejected the rendered post: %s.", err.Error()))
+		}
+
+		return nil
+	}
+}
+
+func parseTemplateArgs(text string, channelID int64) (templateArgs, error) {
+	header, body, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	fields := strings.Fields(header)
+	if len(fields) == 0 {
+		return templateArgs{}, errors.New("The scope is missing")
+	}
+
+	args := templateArgs{
+		scope:     fields[0],
+		parseMode: tgbotapi.ModeMarkdownV2,
+		body:      strings.TrimSpace(body),
+	}
+
+	switch args.scope {
+	case models.TemplateScopeChannel:
+		args.targetID = channelID
+		fields = fields[1:]
+	case models.TemplateScopeSource:
+		if len(fields) < 2 {
+			return templateArgs{}, errors.New("The source ID is missing")
+		}
+		id, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return templateArgs{}, fmt.Errorf("Invalid source ID %q", fields[1])
+		}
+		args.targetID = id
+		fields = fields[2:]
+	default:
+		return templateArgs{}, fmt.Errorf("Unknown scope %q", args.scope)
+	}
+
+	if len(fields) > 0 {
+		switch strings.ToLower(fields[0]) {
+		case "markdown", "markdownv2":
+			args.parseMode = tgbotapi.ModeMarkdownV2
+		case "html":
+			args.parseMode = tgbotapi.ModeHTML
+		default:
+			return templateArgs{}, fmt.Errorf("Unknown parse mode %q", fields[0])
+		}
+	}
+
+	return args, nil
+}
+
+func templateTargetExists(ctx context.Context, sources SourceProvider, args templateArgs) (bool, error) {
+	if args.scope != models.TemplateScopeSource {
+		return true, nil
+	}
+
+	if _, err := sources.SourceByID(ctx, args.targetID); err != nil {
+		if errors.Is(err, repository.ErrorSourceNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("get source: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,31 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/repository"
+)
+
+func ViewCmdUnsubscribe(storage SubscriptionStorage) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		id, err := strconv.ParseInt(strings.TrimSpace(update.Message.CommandArguments()), 10, 64)
+		if err != nil {
+			return sendText(bot, update.Message.Chat.ID, "Usage: /unsubscribe <subscription ID>")
+		}
+
+		if err := storage.Unsubscribe(ctx, update.SentFrom().ID, id); err != nil {
+			if errors.Is(err, repository.ErrorSubscriptionNotFound) {
+				return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Subscription %d not found.", id))
+			}
+			return fmt.Errorf("unsubscribe: %w", err)
+		}
+
+		return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Subscription %d removed.", id))
+	}
+}
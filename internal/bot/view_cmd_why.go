@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/botkit/markup"
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/repository"
+)
+
+func ViewCmdWhy(articles ArticleProvider) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		id, err := strconv.ParseInt(strings.TrimSpace(update.Message.CommandArguments()), 10, 64)
+		if err != nil {
+			return sendText(bot, update.Message.Chat.ID, "Usage: /why <article ID>")
+		}
+
+		article, err := articles.ArticleByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrorArticleNotFound) {
+				return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Article %d not found.", id))
+			}
+			return fmt.Errorf("get article: %w", err)
+		}
+
+		if article.ScoredDate.IsZero() {
+			return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Article %d has not been scored yet.", id))
+		}
+
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, formatScore(article))
+		reply.ParseMode = parseModeMarkdownV2
+
+		if _, err := bot.Send(reply); err != nil {
+			return fmt.Errorf("send message: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func formatScore(article *models.Article) string {
+	message := markup.NewBuilder().
+		Bold(article.Title).Text("\n\n").
+		Text("Score: ").Bold(strconv.FormatFloat(article.Score, 'f', 3, 64)).
+		Textf(" (scored %s)\n", formatDate(article.ScoredDate))
+
+	for _, v := range article.ScoreFactors {
+		message.Textf("\n%s: %.3f × %g = %.3f", v.Name, v.Value, v.Weight, v.Value*v.Weight)
+	}
+
+	return message.MarkdownV2()
+}
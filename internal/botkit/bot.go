@@ -2,6 +2,7 @@ package botkit
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"runtime/debug"
 	"time"
@@ -9,42 +10,91 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+const defaultConversationTTL = 10 * time.Minute
+
 type Bot struct {
-	api      *tgbotapi.BotAPI
-	cmdViews map[string]ViewFunc
+	api           *tgbotapi.BotAPI
+	cmdViews      map[string]ViewFunc
+	commands      map[string]Command
+	callbackViews map[string]ViewFunc
+	callbackRoles map[string]Role
+	// callbackChats are the chats whose members may use the admin buttons of
+	// the namespace
+	callbackChats map[string]map[int64]struct{}
+
+	admins      map[int64]struct{}
+	adminChatID int64
+
+	conversations     map[string]Conversation
+	conversationStore ConversationStore
+	conversationTTL   time.Duration
+
+	workers      int
+	queueSize    int
+	drainTimeout time.Duration
+
+	observer Observer
+}
+
+// Observer is notified of the handled commands and callbacks, e.g. to count
+// them in the metrics. The error is the one returned by the view.
+type Observer interface {
+	CommandHandled(cmd string, err error)
+	CallbackHandled(namespace string, err error)
 }
 
 type ViewFunc func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error
 
 func New(api *tgbotapi.BotAPI) *Bot {
 	return &Bot{
-		api: api,
+		api:               api,
+		conversationStore: NewMemoryConversationStore(),
+		conversationTTL:   defaultConversationTTL,
+		workers:           defaultWorkers,
+		queueSize:         defaultQueueSize,
+		drainTimeout:      defaultDrainTimeout,
 	}
 }
 
-func (b *Bot) RegisterCmdView(cmd string, view ViewFunc) {
+func (b *Bot) RegisterCmdView(cmd string, view ViewFunc, meta Command) {
 	if b.cmdViews == nil {
 		b.cmdViews = make(map[string]ViewFunc)
+		b.commands = make(map[string]Command)
 	}
 	b.cmdViews[cmd] = view
+	b.commands[cmd] = meta
 }
 
+// SetObserver sets the observer of the handled commands and callbacks.
+func (b *Bot) SetObserver(observer Observer) {
+	b.observer = observer
+}
+
+// RegisterCallbackView registers a view for inline keyboard buttons whose
+// callback data was built with NewCallbackData(namespace, version, ...).
+func (b *Bot) RegisterCallbackView(namespace string, version int, view ViewFunc, role Role) {
+	if b.callbackViews == nil {
+		b.callbackViews = make(map[string]ViewFunc)
+		b.callbackRoles = make(map[string]Role)
+	}
+	b.callbackViews[callbackRoute(namespace, version)] = view
+	b.callbackRoles[callbackRoute(namespace, version)] = role
+}
+
+// Run receives updates with long polling.
 func (b *Bot) Run(ctx context.Context) error {
+	// getUpdates is rejected while a webhook left by a previous run is set
+	if _, err := b.api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
 	updates := b.api.GetUpdatesChan(u)
+	defer b.api.StopReceivingUpdates()
 
-	for {
-		select {
-		case update := <-updates:
-			updateContext, updateChannel := context.WithTimeout(ctx, 5*time.Minute)
-			b.handleUpdate(updateContext, update)
-			updateChannel()
-		case <-ctx.Done():
-			return ctx.Err()
-		}
-	}
+	return b.serve(ctx, updates)
 }
 
 func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
@@ -54,32 +104,101 @@ func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 		}
 	}()
 
-	if (update.Message == nil || !update.Message.IsCommand()) && update.CallbackQuery == nil {
-		return
-	}
-	var view ViewFunc
-	if !update.Message.IsCommand() {
-		return
+	switch {
+	case update.CallbackQuery != nil:
+		b.handleCallback(ctx, update)
+	case update.Message != nil && update.Message.IsCommand():
+		b.handleCommand(ctx, update)
+	case update.Message != nil:
+		b.handleMessage(ctx, update)
 	}
+}
+
+func (b *Bot) handleCommand(ctx context.Context, update tgbotapi.Update) {
 	cmd := update.Message.Command()
-	cmdView, ok := b.cmdViews[cmd]
-	if !ok {
+
+	view, ok := b.cmdViews[cmd]
+	if _, isConversation := b.conversations[cmd]; isConversation && (!ok || update.Message.CommandArguments() == "") {
+		view = func(ctx context.Context, _ *tgbotapi.BotAPI, update tgbotapi.Update) error {
+			return b.StartConversation(ctx, update.Message.Chat.ID, cmd, nil)
+		}
+	} else if !ok {
 		return
 	}
-	view = cmdView
-	if err := view(ctx, b.api, update); err != nil {
-		slog.With("error", err.Error()).ErrorContext(ctx, "handling update")
 
-		if _, err := b.api.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "internal error")); err != nil {
+	if !b.allowed(cmd, update) {
+		if err := b.reply(update, "You are not allowed to use this command."); err != nil {
 			slog.With("error", err.Error()).ErrorContext(ctx, "send message")
 		}
+		return
+	}
+
+	err := view(ctx, b.api, update)
+	if b.observer != nil {
+		b.observer.CommandHandled(cmd, err)
+	}
+	if err != nil {
+		b.replyInternalError(ctx, update, err)
+	}
+}
+
+func (b *Bot) handleMessage(ctx context.Context, update tgbotapi.Update) {
+	if err := b.continueConversation(ctx, update); err != nil {
+		b.replyInternalError(ctx, update, err)
+	}
+}
+
+func (b *Bot) replyInternalError(ctx context.Context, update tgbotapi.Update, err error) {
+	slog.With("error", err.Error()).ErrorContext(ctx, "handling update")
+
+	if _, err := b.api.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "internal error")); err != nil {
+		slog.With("error", err.Error()).ErrorContext(ctx, "send message")
 	}
 }
 
-func (b *Bot) GetCommandNames() []string {
-	var names []string
-	for name := range b.cmdViews {
-		names = append(names, name)
+func (b *Bot) handleCallback(ctx context.Context, update tgbotapi.Update) {
+	query := update.CallbackQuery
+	answer := &callbackAnswer{}
+	defer func() {
+		callback := tgbotapi.NewCallback(query.ID, answer.text)
+		callback.ShowAlert = answer.alert
+		if _, err := b.api.Request(callback); err != nil {
+			slog.With("error", err.Error()).ErrorContext(ctx, "answer callback query")
+		}
+	}()
+
+	// buttons attached to inline messages have no message to edit
+	if query.Message == nil {
+		return
+	}
+
+	data, err := ParseCallbackData(query.Data)
+	if err != nil {
+		slog.With("error", err.Error()).WarnContext(ctx, "parse callback data", "data", query.Data)
+		return
+	}
+	if data.Namespace == noopNamespace {
+		return
+	}
+
+	route := callbackRoute(data.Namespace, data.Version)
+	view, ok := b.callbackViews[route]
+	if !ok {
+		answer.text = "This button is outdated"
+		return
+	}
+	if b.callbackRoles[route] == RoleAdmin && !b.IsAdmin(update) && !b.callbackChatAllowed(data.Namespace, query.Message.Chat) {
+		answer.text = "You are not allowed to use this button"
+		return
+	}
+
+	err = view(context.WithValue(ctx, callbackAnswerKey{}, answer), b.api, update)
+	if b.observer != nil {
+		b.observer.CallbackHandled(data.Namespace, err)
+	}
+	if err != nil {
+		slog.With("error", err.Error()).ErrorContext(ctx, "handling callback", "data", query.Data)
+		answer.text = "internal error"
+		answer.alert = true
 	}
-	return names
 }
@@ -0,0 +1,128 @@
+package botkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	callbackSeparator   = ":"
+	callbackDataMaxSize = 64
+
+	noopNamespace = "noop"
+)
+
+var (
+	ErrCallbackDataTooLong = errors.New("callback data too long")
+	ErrInvalidCallbackData = errors.New("invalid callback data")
+)
+
+// CallbackData is the payload of an inline keyboard button in the form
+// "namespace:version:arg1:arg2...". The version lets a handler reject buttons
+// rendered by an older release of the bot.
+type CallbackData struct {
+	Namespace string
+	Version   int
+	Args      []string
+}
+
+func NewCallbackData(namespace string, version int, args ...string) CallbackData {
+	return CallbackData{
+		Namespace: namespace,
+		Version:   version,
+		Args:      args,
+	}
+}
+
+func (d CallbackData) Encode() (string, error) {
+	if d.Namespace == "" || strings.Contains(d.Namespace, callbackSeparator) {
+		return "", fmt.Errorf("namespace %q: %w", d.Namespace, ErrInvalidCallbackData)
+	}
+	for _, arg := range d.Args {
+		if strings.Contains(arg, callbackSeparator) {
+			return "", fmt.Errorf("argument %q: %w", arg, ErrInvalidCallbackData)
+		}
+	}
+
+	parts := append([]string{d.Namespace, strconv.Itoa(d.Version)}, d.Args...)
+	data := strings.Join(parts, callbackSeparator)
+	if len(data) > callbackDataMaxSize {
+		return "", fmt.Errorf("%d bytes: %w", len(data), ErrCallbackDataTooLong)
+	}
+
+	return data, nil
+}
+
+// MustEncode is like Encode but panics on error. It is meant for callback data
+// built from trusted values such as IDs and page numbers.
+func (d CallbackData) MustEncode() string {
+	data, err := d.Encode()
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func (d CallbackData) Arg(i int) string {
+	if i < 0 || i >= len(d.Args) {
+		return ""
+	}
+	return d.Args[i]
+}
+
+func (d CallbackData) IntArg(i int) (int64, error) {
+	value, err := strconv.ParseInt(d.Arg(i), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("argument %d: %w", i, ErrInvalidCallbackData)
+	}
+	return value, nil
+}
+
+func ParseCallbackData(src string) (CallbackData, error) {
+	parts := strings.Split(src, callbackSeparator)
+	if len(parts) < 2 || parts[0] == "" {
+		return CallbackData{}, ErrInvalidCallbackData
+	}
+
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return CallbackData{}, fmt.Errorf("version %q: %w", parts[1], ErrInvalidCallbackData)
+	}
+
+	return CallbackData{
+		Namespace: parts[0],
+		Version:   version,
+		Args:      parts[2:],
+	}, nil
+}
+
+// NoopButton returns a button which does nothing when pressed, e.g. to show
+// a status in place of buttons that were already used.
+func NoopButton(text string) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData(text, NewCallbackData(noopNamespace, 1).MustEncode())
+}
+
+func callbackRoute(namespace string, version int) string {
+	return namespace + callbackSeparator + strconv.Itoa(version)
+}
+
+type callbackAnswer struct {
+	text  string
+	alert bool
+}
+
+type callbackAnswerKey struct{}
+
+// SetCallbackAnswer sets the text shown to the user when the router answers
+// the callback query being handled.
+func SetCallbackAnswer(ctx context.Context, text string, alert bool) {
+	if answer, ok := ctx.Value(callbackAnswerKey{}).(*callbackAnswer); ok {
+		answer.text = text
+		answer.alert = alert
+	}
+}
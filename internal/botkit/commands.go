@@ -0,0 +1,177 @@
+package botkit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type Role int
+
+const (
+	RoleUser Role = iota
+	RoleAdmin
+)
+
+// Command describes a command for the generated help text and the command
+// menu of Telegram clients.
+type Command struct {
+	Description string
+	Usage       string
+	Hidden      bool
+	Role        Role
+	// Descriptions holds translations of Description by IETF language code.
+	Descriptions map[string]string
+}
+
+func (c Command) description(lang string) string {
+	if description, ok := c.Descriptions[lang]; ok {
+		return description
+	}
+	return c.Description
+}
+
+func (b *Bot) SetAdmins(userIDs []int64, adminChatID int64) {
+	b.admins = make(map[int64]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		b.admins[id] = struct{}{}
+	}
+	b.adminChatID = adminChatID
+}
+
+// IsAdmin reports whether the update was sent by an admin or to the admin chat.
+func (b *Bot) IsAdmin(update tgbotapi.Update) bool {
+	if user := update.SentFrom(); user != nil {
+		if _, ok := b.admins[user.ID]; ok {
+			return true
+		}
+	}
+
+	var chat *tgbotapi.Chat
+	if update.CallbackQuery != nil {
+		if update.CallbackQuery.Message != nil {
+			chat = update.CallbackQuery.Message.Chat
+		}
+	} else {
+		chat = update.FromChat()
+	}
+
+	return b.adminChatID != 0 && chat != nil && chat.ID == b.adminChatID
+}
+
+// AllowCallbackChat lets everyone in the chat use the admin buttons of the
+// namespace, e.g. the editors in the moderation chat who are not admins.
+func (b *Bot) AllowCallbackChat(namespace string, chatID int64) {
+	if b.callbackChats == nil {
+		b.callbackChats = make(map[string]map[int64]struct{})
+	}
+	if b.callbackChats[namespace] == nil {
+		b.callbackChats[namespace] = make(map[int64]struct{})
+	}
+	b.callbackChats[namespace][chatID] = struct{}{}
+}
+
+func (b *Bot) callbackChatAllowed(namespace string, chat *tgbotapi.Chat) bool {
+	if chat == nil {
+		return false
+	}
+	_, ok := b.callbackChats[namespace][chat.ID]
+	return ok
+}
+
+func (b *Bot) allowed(cmd string, update tgbotapi.Update) bool {
+	return b.commands[cmd].Role != RoleAdmin || b.IsAdmin(update)
+}
+
+// HelpText lists the visible commands available to the sender of the update.
+func (b *Bot) HelpText(update tgbotapi.Update) string {
+	var lang string
+	if user := update.SentFrom(); user != nil {
+		lang = user.LanguageCode
+	}
+
+	var help strings.Builder
+	help.WriteString("List of commands:\n")
+	for _, name := range b.GetCommandNames() {
+		cmd := b.commands[name]
+		if cmd.Hidden || !b.allowed(name, update) {
+			continue
+		}
+
+		help.WriteString("/" + name)
+		if cmd.Usage != "" {
+			help.WriteString(" " + cmd.Usage)
+		}
+		if description := cmd.description(lang); description != "" {
+			help.WriteString(" — " + description)
+		}
+		help.WriteString("\n")
+	}
+
+	return help.String()
+}
+
+// SyncCommands publishes the command menu: user commands for private chats and
+// user and admin commands for the admin chat and private chats with admins,
+// once for the default language and once per translated language.
+func (b *Bot) SyncCommands() error {
+	languages := map[string]struct{}{"": {}}
+	for _, cmd := range b.commands {
+		for lang := range cmd.Descriptions {
+			languages[lang] = struct{}{}
+		}
+	}
+
+	var adminScopes []tgbotapi.BotCommandScope
+	if b.adminChatID != 0 {
+		adminScopes = append(adminScopes, tgbotapi.NewBotCommandScopeChat(b.adminChatID))
+	}
+	for id := range b.admins {
+		adminScopes = append(adminScopes, tgbotapi.NewBotCommandScopeChat(id))
+	}
+
+	for lang := range languages {
+		config := tgbotapi.NewSetMyCommandsWithScopeAndLanguage(
+			tgbotapi.NewBotCommandScopeAllPrivateChats(), lang, b.botCommands(lang, false)...)
+		if _, err := b.api.Request(config); err != nil {
+			return fmt.Errorf("set commands for private chats, language %q: %w", lang, err)
+		}
+
+		for _, scope := range adminScopes {
+			config := tgbotapi.NewSetMyCommandsWithScopeAndLanguage(scope, lang, b.botCommands(lang, true)...)
+			if _, err := b.api.Request(config); err != nil {
+				return fmt.Errorf("set commands for chat %d, language %q: %w", scope.ChatID, lang, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *Bot) botCommands(lang string, admin bool) []tgbotapi.BotCommand {
+	var commands []tgbotapi.BotCommand
+	for _, name := range b.GetCommandNames() {
+		cmd := b.commands[name]
+		if cmd.Hidden || (cmd.Role == RoleAdmin && !admin) {
+			continue
+		}
+
+		description := cmd.description(lang)
+		if description == "" {
+			description = name
+		}
+		commands = append(commands, tgbotapi.BotCommand{Command: name, Description: description})
+	}
+	return commands
+}
+
+func (b *Bot) GetCommandNames() []string {
+	var names []string
+	for name := range b.cmdViews {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
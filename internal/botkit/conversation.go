@@ -0,0 +1,228 @@
+package botkit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// CancelCommand stops the conversation in the chat, the prompts of the
+// conversations tell to send it. It must be registered with ViewCmdCancel.
+const CancelCommand = "cancel"
+
+type Step struct {
+	Key    string
+	Prompt string
+	// Validate checks the user's answer; the error text is sent back to the user
+	// and the step is asked again.
+	Validate func(text string) error
+}
+
+type CompleteFunc func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update, answers map[string]string) error
+
+// Conversation is a dialog started by a command without arguments that asks
+// the user the Steps one by one and calls Complete with the collected answers.
+type Conversation struct {
+	Steps    []Step
+	Complete CompleteFunc
+}
+
+type ConversationState struct {
+	ChatID    int64
+	Name      string
+	Step      int
+	Answers   map[string]string
+	ExpiresAt time.Time
+}
+
+// ConversationStore keeps the dialog state of chats. Get returns nil without
+// an error when the chat has no active or only an expired conversation.
+type ConversationStore interface {
+	Get(ctx context.Context, chatID int64) (*ConversationState, error)
+	Save(ctx context.Context, state ConversationState) error
+	Delete(ctx context.Context, chatID int64) error
+}
+
+type MemoryConversationStore struct {
+	mu     sync.Mutex
+	states map[int64]ConversationState
+}
+
+func NewMemoryConversationStore() *MemoryConversationStore {
+	return &MemoryConversationStore{
+		states: make(map[int64]ConversationState),
+	}
+}
+
+func (s *MemoryConversationStore) Get(_ context.Context, chatID int64) (*ConversationState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[chatID]
+	if !ok {
+		return nil, nil
+	}
+	if !state.ExpiresAt.After(time.Now()) {
+		delete(s.states, chatID)
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+func (s *MemoryConversationStore) Save(_ context.Context, state ConversationState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[state.ChatID] = state
+	return nil
+}
+
+func (s *MemoryConversationStore) Delete(_ context.Context, chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.states, chatID)
+	return nil
+}
+
+func (b *Bot) RegisterConversation(cmd string, conversation Conversation) {
+	if b.conversations == nil {
+		b.conversations = make(map[string]Conversation)
+	}
+	b.conversations[cmd] = conversation
+}
+
+func (b *Bot) SetConversationStore(store ConversationStore, ttl time.Duration) {
+	b.conversationStore = store
+	b.conversationTTL = ttl
+}
+
+// StartConversation starts the conversation registered as name in the chat.
+// Steps with keys present in answers are skipped, so callers may preset
+// answers known from the context, e.g. the ID of an article behind a button.
+func (b *Bot) StartConversation(ctx context.Context, chatID int64, name string, answers map[string]string) error {
+	conversation, ok := b.conversations[name]
+	if !ok {
+		return fmt.Errorf("conversation %s is not registered", name)
+	}
+
+	state := ConversationState{
+		ChatID:    chatID,
+		Name:      name,
+		Answers:   make(map[string]string),
+		ExpiresAt: time.Now().Add(b.conversationTTL),
+	}
+	for key, value := range answers {
+		state.Answers[key] = value
+	}
+	state.Step = nextStep(conversation, state.Answers, 0)
+	if state.Step >= len(conversation.Steps) {
+		return fmt.Errorf("conversation %s has no steps left to ask", name)
+	}
+
+	if err := b.conversationStore.Save(ctx, state); err != nil {
+		return fmt.Errorf("save conversation: %w", err)
+	}
+
+	if err := b.send(chatID, conversation.Steps[state.Step].Prompt+"\n\nSend /"+CancelCommand+" to stop."); err != nil {
+		// the next message to the chat must not be taken for an answer
+		if err := b.conversationStore.Delete(ctx, chatID); err != nil {
+			slog.With("error", err.Error()).ErrorContext(ctx, "delete conversation")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// nextStep returns the index of the first step starting from i which has no
+// answer yet.
+func nextStep(conversation Conversation, answers map[string]string, i int) int {
+	for i < len(conversation.Steps) {
+		if _, ok := answers[conversation.Steps[i].Key]; !ok {
+			break
+		}
+		i++
+	}
+	return i
+}
+
+// ViewCmdCancel is the view of CancelCommand.
+func (b *Bot) ViewCmdCancel() ViewFunc {
+	return func(ctx context.Context, _ *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		return b.cancelConversation(ctx, update)
+	}
+}
+
+func (b *Bot) cancelConversation(ctx context.Context, update tgbotapi.Update) error {
+	state, err := b.conversationStore.Get(ctx, update.Message.Chat.ID)
+	if err != nil {
+		return fmt.Errorf("get conversation: %w", err)
+	}
+	if state == nil {
+		return b.reply(update, "Nothing to cancel.")
+	}
+
+	if err := b.conversationStore.Delete(ctx, state.ChatID); err != nil {
+		return fmt.Errorf("delete conversation: %w", err)
+	}
+
+	return b.reply(update, "Cancelled.")
+}
+
+// continueConversation handles a plain message sent to a chat with an active
+// conversation. Messages to chats without one are ignored.
+func (b *Bot) continueConversation(ctx context.Context, update tgbotapi.Update) error {
+	state, err := b.conversationStore.Get(ctx, update.Message.Chat.ID)
+	if err != nil {
+		return fmt.Errorf("get conversation: %w", err)
+	}
+	if state == nil {
+		return nil
+	}
+
+	conversation, ok := b.conversations[state.Name]
+	if !ok || state.Step >= len(conversation.Steps) {
+		return b.conversationStore.Delete(ctx, state.ChatID)
+	}
+
+	step := conversation.Steps[state.Step]
+	if step.Validate != nil {
+		if err := step.Validate(update.Message.Text); err != nil {
+			return b.reply(update, fmt.Sprintf("%s\n\n%s", err.Error(), step.Prompt))
+		}
+	}
+
+	state.Answers[step.Key] = update.Message.Text
+	state.Step = nextStep(conversation, state.Answers, state.Step+1)
+	state.ExpiresAt = time.Now().Add(b.conversationTTL)
+
+	if state.Step < len(conversation.Steps) {
+		if err := b.conversationStore.Save(ctx, *state); err != nil {
+			return fmt.Errorf("save conversation: %w", err)
+		}
+		return b.reply(update, conversation.Steps[state.Step].Prompt)
+	}
+
+	if err := b.conversationStore.Delete(ctx, state.ChatID); err != nil {
+		return fmt.Errorf("delete conversation: %w", err)
+	}
+
+	return conversation.Complete(ctx, b.api, update, state.Answers)
+}
+
+func (b *Bot) reply(update tgbotapi.Update, text string) error {
+	return b.send(update.Message.Chat.ID, text)
+}
+
+func (b *Bot) send(chatID int64, text string) error {
+	if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package botkit
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// supergroupIDOffset is added to the internal ID of channels and supergroups
+// to form their bot API chat ID, e.g. -1001234567890.
+const supergroupIDOffset = -1000000000000
+
+// MessageLink returns a t.me link to a message in a public chat or a private
+// channel or supergroup. Messages in other chats have no link.
+func MessageLink(chat *tgbotapi.Chat, messageID int) string {
+	switch {
+	case chat == nil:
+		return ""
+	case chat.UserName != "":
+		return fmt.Sprintf("https://t.me/%s/%d", chat.UserName, messageID)
+	case chat.ID < supergroupIDOffset:
+		return fmt.Sprintf("https://t.me/c/%d/%d", -(chat.ID - supergroupIDOffset), messageID)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,306 @@
+package markup
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	kindText    = ""
+	kindBold    = "bold"
+	kindItalic  = "italic"
+	kindCode    = "code"
+	kindPre     = "pre"
+	kindLink    = "text_link"
+	kindMention = "text_mention"
+	kindSpoiler = "spoiler"
+	kindQuote   = "blockquote"
+)
+
+// Node is a piece of a formatted message: plain text or an entity wrapping
+// other nodes. The kinds of the entities are the Telegram entity types.
+type Node struct {
+	kind     string
+	text     string
+	url      string
+	userID   int64
+	language string
+	children []Node
+}
+
+func Text(text string) Node {
+	return Node{text: text}
+}
+
+func Textf(format string, args ...any) Node {
+	return Node{text: fmt.Sprintf(format, args...)}
+}
+
+func Bold(children ...Node) Node {
+	return Node{kind: kindBold, children: children}
+}
+
+func Italic(children ...Node) Node {
+	return Node{kind: kindItalic, children: children}
+}
+
+func Code(text string) Node {
+	return Node{kind: kindCode, text: text}
+}
+
+// Pre is a preformatted block of code, the language is optional.
+func Pre(text, language string) Node {
+	return Node{kind: kindPre, text: text, language: language}
+}
+
+func Link(url string, children ...Node) Node {
+	return Node{kind: kindLink, url: url, children: children}
+}
+
+// Mention links to the user by ID, which works for users without a username.
+func Mention(userID int64, children ...Node) Node {
+	return Node{kind: kindMention, userID: userID, children: children}
+}
+
+func Spoiler(children ...Node) Node {
+	return Node{kind: kindSpoiler, children: children}
+}
+
+// Quote is a block quotation. It should start on a new line.
+func Quote(children ...Node) Node {
+	return Node{kind: kindQuote, children: children}
+}
+
+// Builder builds a formatted message which renders to MarkdownV2, HTML or
+// plain text with entities.
+type Builder struct {
+	nodes []Node
+}
+
+func NewBuilder(nodes ...Node) *Builder {
+	return &Builder{nodes: nodes}
+}
+
+// Append adds the nodes to the message, e.g. nested entities.
+func (b *Builder) Append(nodes ...Node) *Builder {
+	b.nodes = append(b.nodes, nodes...)
+	return b
+}
+
+func (b *Builder) Text(text string) *Builder {
+	return b.Append(Text(text))
+}
+
+func (b *Builder) Textf(format string, args ...any) *Builder {
+	return b.Append(Textf(format, args...))
+}
+
+func (b *Builder) Bold(text string) *Builder {
+	return b.Append(Bold(Text(text)))
+}
+
+func (b *Builder) Italic(text string) *Builder {
+	return b.Append(Italic(Text(text)))
+}
+
+func (b *Builder) Code(text string) *Builder {
+	return b.Append(Code(text))
+}
+
+func (b *Builder) Pre(text, language string) *Builder {
+	return b.Append(Pre(text, language))
+}
+
+func (b *Builder) Link(text, url string) *Builder {
+	return b.Append(Link(url, Text(text)))
+}
+
+func (b *Builder) Mention(text string, userID int64) *Builder {
+	return b.Append(Mention(userID, Text(text)))
+}
+
+func (b *Builder) Spoiler(text string) *Builder {
+	return b.Append(Spoiler(Text(text)))
+}
+
+func (b *Builder) Quote(text string) *Builder {
+	return b.Append(Quote(Text(text)))
+}
+
+// Len returns the length of the message the way Telegram measures it: in
+// UTF-16 code units of the text without markup.
+func (b *Builder) Len() int {
+	text, _ := b.Entities()
+	return UTF16Len(text)
+}
+
+// Render renders the message in the parse mode. The empty parse mode renders
+// plain text.
+func (b *Builder) Render(parseMode string) string {
+	switch parseMode {
+	case tgbotapi.ModeMarkdownV2:
+		return b.MarkdownV2()
+	case tgbotapi.ModeHTML:
+		return b.HTML()
+	default:
+		text, _ := b.Entities()
+		return text
+	}
+}
+
+func (b *Builder) MarkdownV2() string {
+	var sb strings.Builder
+	for _, v := range b.nodes {
+		v.markdown(&sb)
+	}
+	return sb.String()
+}
+
+func (b *Builder) HTML() string {
+	var sb strings.Builder
+	for _, v := range b.nodes {
+		v.html(&sb)
+	}
+	return sb.String()
+}
+
+// Entities returns the plain text of the message and its entities, to be sent
+// without a parse mode.
+func (b *Builder) Entities() (string, []tgbotapi.MessageEntity) {
+	var (
+		sb       strings.Builder
+		offset   int
+		entities []tgbotapi.MessageEntity
+	)
+	for _, v := range b.nodes {
+		v.entities(&sb, &offset, &entities)
+	}
+
+	sort.SliceStable(entities, func(i, j int) bool {
+		return entities[i].Offset < entities[j].Offset
+	})
+	return sb.String(), entities
+}
+
+func (n Node) markdown(sb *strings.Builder) {
+	children := func() string {
+		var inner strings.Builder
+		for _, v := range n.children {
+			v.markdown(&inner)
+		}
+		return inner.String()
+	}
+
+	switch n.kind {
+	case kindText:
+		sb.WriteString(EscapeForMarkdown(n.text))
+	case kindBold:
+		sb.WriteString("*" + children() + "*")
+	case kindItalic:
+		sb.WriteString("_" + children() + "_")
+	case kindCode:
+		sb.WriteString("`" + EscapeForMarkdownCode(n.text) + "`")
+	case kindPre:
+		sb.WriteString("```" + n.language + "\n" + EscapeForMarkdownCode(n.text) + "\n```")
+	case kindLink:
+		sb.WriteString("[" + children() + "](" + EscapeForMarkdownLink(n.url) + ")")
+	case kindMention:
+		sb.WriteString("[" + children() + "](" + EscapeForMarkdownLink(mentionURL(n.userID)) + ")")
+	case kindSpoiler:
+		sb.WriteString("||" + children() + "||")
+	case kindQuote:
+		sb.WriteString(">" + strings.ReplaceAll(children(), "\n", "\n>"))
+	}
+}
+
+func (n Node) html(sb *strings.Builder) {
+	children := func() string {
+		var inner strings.Builder
+		for _, v := range n.children {
+			v.html(&inner)
+		}
+		return inner.String()
+	}
+
+	switch n.kind {
+	case kindText:
+		sb.WriteString(html.EscapeString(n.text))
+	case kindBold:
+		sb.WriteString("<b>" + children() + "</b>")
+	case kindItalic:
+		sb.WriteString("<i>" + children() + "</i>")
+	case kindCode:
+		sb.WriteString("<code>" + html.EscapeString(n.text) + "</code>")
+	case kindPre:
+		if n.language == "" {
+			sb.WriteString("<pre>" + html.EscapeString(n.text) + "</pre>")
+		} else {
+			sb.WriteString(`<pre><code class="language-` + html.EscapeString(n.language) + `">` +
+				html.EscapeString(n.text) + "</code></pre>")
+		}
+	case kindLink:
+		sb.WriteString(`<a href="` + html.EscapeString(n.url) + `">` + children() + "</a>")
+	case kindMention:
+		sb.WriteString(`<a href="` + mentionURL(n.userID) + `">` + children() + "</a>")
+	case kindSpoiler:
+		sb.WriteString("<tg-spoiler>" + children() + "</tg-spoiler>")
+	case kindQuote:
+		sb.WriteString("<blockquote>" + children() + "</blockquote>")
+	}
+}
+
+func (n Node) entities(sb *strings.Builder, offset *int, entities *[]tgbotapi.MessageEntity) {
+	start := *offset
+
+	if n.kind == kindText || n.kind == kindCode || n.kind == kindPre {
+		sb.WriteString(n.text)
+		*offset += UTF16Len(n.text)
+	} else {
+		for _, v := range n.children {
+			v.entities(sb, offset, entities)
+		}
+	}
+
+	if n.kind == kindText || *offset == start {
+		return
+	}
+
+	entity := tgbotapi.MessageEntity{
+		Type:     n.kind,
+		Offset:   start,
+		Length:   *offset - start,
+		URL:      n.url,
+		Language: n.language,
+	}
+	if n.kind == kindMention {
+		entity.User = &tgbotapi.User{ID: n.userID}
+	}
+	*entities = append(*entities, entity)
+}
+
+func mentionURL(userID int64) string {
+	return "tg://user?id=" + strconv.FormatInt(userID, 10)
+}
+
+// UTF16Len returns the length of the text in UTF-16 code units, which is how
+// Telegram measures texts and entity offsets.
+func UTF16Len(text string) int {
+	var length int
+	for _, r := range text {
+		length += RuneLen(r)
+	}
+	return length
+}
+
+// RuneLen returns the number of UTF-16 code units of the rune.
+func RuneLen(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
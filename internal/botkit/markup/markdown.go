@@ -4,6 +4,8 @@ import "strings"
 
 var (
 	replacer = strings.NewReplacer(
+		"\\",
+		"\\\\",
 		"-",
 		"\\-",
 		"_",
@@ -43,6 +45,31 @@ var (
 	)
 )
 
+var (
+	linkReplacer = strings.NewReplacer(
+		"\\",
+		"\\\\",
+		")",
+		"\\)",
+	)
+	codeReplacer = strings.NewReplacer(
+		"\\",
+		"\\\\",
+		"`",
+		"\\`",
+	)
+)
+
 func EscapeForMarkdown(text string) string {
 	return replacer.Replace(text)
 }
+
+// EscapeForMarkdownCode escapes the text of inline code and pre blocks.
+func EscapeForMarkdownCode(text string) string {
+	return codeReplacer.Replace(text)
+}
+
+// EscapeForMarkdownLink escapes the URL part of an inline link (...).
+func EscapeForMarkdownLink(url string) string {
+	return linkReplacer.Replace(url)
+}
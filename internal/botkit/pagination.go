@@ -0,0 +1,81 @@
+package botkit
+
+import (
+	"fmt"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Page describes a zero-based page of a list with Total elements.
+type Page struct {
+	Number int
+	Size   int
+	Total  int
+}
+
+func NewPage(number, size, total int) Page {
+	p := Page{Number: number, Size: size, Total: total}
+	if p.Size <= 0 {
+		p.Size = 1
+	}
+	if p.Number >= p.Count() {
+		p.Number = p.Count() - 1
+	}
+	if p.Number < 0 {
+		p.Number = 0
+	}
+	return p
+}
+
+func (p Page) Count() int {
+	if p.Total <= 0 {
+		return 1
+	}
+	return (p.Total + p.Size - 1) / p.Size
+}
+
+func (p Page) Offset() int {
+	return p.Number * p.Size
+}
+
+// Bounds returns the slice bounds of the page within a list of Total elements.
+func (p Page) Bounds() (int, int) {
+	start := min(p.Offset(), max(p.Total, 0))
+	end := min(start+p.Size, max(p.Total, 0))
+	return start, end
+}
+
+func (p Page) HasPrev() bool {
+	return p.Number > 0
+}
+
+func (p Page) HasNext() bool {
+	return p.Number < p.Count()-1
+}
+
+// PaginationKeyboard renders navigation buttons for the page. Every button
+// carries callback data "namespace:version:page:args...", so the callback view
+// can read the requested page with IntArg(0). It returns false when the list
+// fits into a single page and no keyboard is needed.
+func PaginationKeyboard(namespace string, version int, page Page, args ...string) (tgbotapi.InlineKeyboardMarkup, bool) {
+	if page.Count() <= 1 {
+		return tgbotapi.InlineKeyboardMarkup{}, false
+	}
+
+	button := func(text string, number int) tgbotapi.InlineKeyboardButton {
+		data := NewCallbackData(namespace, version, append([]string{strconv.Itoa(number)}, args...)...)
+		return tgbotapi.NewInlineKeyboardButtonData(text, data.MustEncode())
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	if page.HasPrev() {
+		row = append(row, button("«", 0), button("‹", page.Number-1))
+	}
+	row = append(row, NoopButton(fmt.Sprintf("%d/%d", page.Number+1, page.Count())))
+	if page.HasNext() {
+		row = append(row, button("›", page.Number+1), button("»", page.Count()-1))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(row), true
+}
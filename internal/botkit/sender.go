@@ -0,0 +1,425 @@
+package botkit
+
+import (
+	"context"
+	"errors"
+	"html"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit/markup"
+	"github.com/to77e/news-fetching-bot/internal/retry"
+)
+
+const (
+	// MessageMaxSize is the maximum length of a message text in UTF-16 code
+	// units.
+	MessageMaxSize = 4096
+
+	defaultMaxRetries = 5
+	defaultRetryDelay = time.Second
+	maxRetryDelay     = time.Minute
+)
+
+var (
+	htmlTags           = regexp.MustCompile(`<[^>]*>`)
+	markdownLinks      = regexp.MustCompile(`\[([^\]]*)\]\(([^)]*)\)`)
+	markdownEntityMark = regexp.MustCompile(`(^|[^\\])(\|\||[*_~` + "`" + `])`)
+	markdownEscape     = regexp.MustCompile(`\\(.)`)
+)
+
+// Sender sends messages to Telegram, waiting out flood limits and retrying
+// transient errors, splitting texts longer than the message limit and
+// falling back to plain text when Telegram can't parse the entities.
+type Sender struct {
+	api        *tgbotapi.BotAPI
+	maxRetries int
+	retryDelay time.Duration
+}
+
+func NewSender(api *tgbotapi.BotAPI) *Sender {
+	return &Sender{
+		api:        api,
+		maxRetries: defaultMaxRetries,
+		retryDelay: defaultRetryDelay,
+	}
+}
+
+// SetRetries sets how many times a request is retried and the delay before
+// the first retry, which doubles with every attempt.
+func (s *Sender) SetRetries(maxRetries int, retryDelay time.Duration) {
+	s.maxRetries = maxRetries
+	s.retryDelay = retryDelay
+}
+
+// Send sends the message, retrying it on flood limits and errors after which
+// the message surely wasn't sent. Other errors are returned right away,
+// since the message may have been sent and a retry would duplicate it.
+func (s *Sender) Send(ctx context.Context, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	var message tgbotapi.Message
+	err := s.retry(ctx, false, func() error {
+		var err error
+		message, err = s.api.Send(c)
+		return err
+	})
+	return message, err
+}
+
+// Request is like Send for idempotent methods which don't return a message,
+// such as editing the keyboard of a message, and also retries them on
+// network and server errors.
+func (s *Sender) Request(ctx context.Context, c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	var resp *tgbotapi.APIResponse
+	err := s.retry(ctx, true, func() error {
+		var err error
+		resp, err = s.api.Request(c)
+		return err
+	})
+	return resp, err
+}
+
+// SendText sends the text message split into parts which fit into the
+// message limit. The reply markup is attached to the last part. A part which
+// Telegram fails to parse is sent again as plain text. It returns the sent
+// messages.
+func (s *Sender) SendText(ctx context.Context, msg tgbotapi.MessageConfig) ([]tgbotapi.Message, error) {
+	parts := SplitText(msg.Text, msg.ParseMode, MessageMaxSize)
+
+	messages := make([]tgbotapi.Message, 0, len(parts))
+	for i, part := range parts {
+		partMsg := msg
+		partMsg.Text = part
+		if i < len(parts)-1 {
+			partMsg.ReplyMarkup = nil
+		}
+		if i > 0 {
+			partMsg.ReplyToMessageID = 0
+		}
+
+		message, err := s.Send(ctx, partMsg)
+		if err != nil && partMsg.ParseMode != "" && IsEntityParseError(err) {
+			slog.With("error", err.Error()).WarnContext(ctx, "send message, falling back to plain text")
+
+			partMsg.Text = PlainText(part, partMsg.ParseMode)
+			partMsg.ParseMode = ""
+			message, err = s.Send(ctx, partMsg)
+		}
+		if err != nil {
+			return messages, err
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+func (s *Sender) retry(ctx context.Context, idempotent bool, request func() error) error {
+	policy := retry.Policy{
+		MaxAttempts: s.maxRetries + 1,
+		Delay:       s.retryDelay,
+		MaxDelay:    maxRetryDelay,
+		OnRetry: func(err error, attempt int, wait time.Duration) {
+			slog.With("error", err.Error()).WarnContext(ctx, "telegram request failed, retrying", "attempt", attempt, "wait", wait)
+		},
+	}
+
+	_, err := retry.Do(ctx, policy, func(context.Context) error {
+		err := request()
+		if wait, ok := retryAfter(err, idempotent); ok {
+			return retry.After(err, wait)
+		}
+		return err
+	})
+	return err
+}
+
+// retryAfter returns how long to wait before retrying the request which
+// failed with the error, zero for the backoff delay, and whether it should be
+// retried at all. Requests which are not idempotent are only retried if
+// Telegram surely didn't handle them: on flood limits and when the connection
+// couldn't be made.
+func retryAfter(err error, idempotent bool) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		// network errors
+		return 0, idempotent || isDialError(err)
+	}
+
+	switch {
+	case apiErr.RetryAfter > 0:
+		return time.Duration(apiErr.RetryAfter) * time.Second, true
+	case apiErr.Code == http.StatusTooManyRequests:
+		return 0, true
+	case apiErr.Code >= http.StatusInternalServerError:
+		return 0, idempotent
+	default:
+		return 0, false
+	}
+}
+
+// isDialError reports whether the request failed before it was sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// IsEntityParseError reports whether Telegram rejected the message because
+// of its markup.
+func IsEntityParseError(err error) bool {
+	return isBadRequest(err, "can't parse entities")
+}
+
+// IsNotModifiedError reports whether Telegram rejected editing the message
+// because the new content is the same.
+func IsNotModifiedError(err error) bool {
+	return isBadRequest(err, "message is not modified")
+}
+
+// IsMessageNotFoundError reports whether the message to edit or delete no
+// longer exists.
+func IsMessageNotFoundError(err error) bool {
+	return isBadRequest(err, "message to edit not found") || isBadRequest(err, "message to delete not found")
+}
+
+// IsChatUnavailableError reports whether messages can't be sent to the chat
+// for good, e.g. because the user blocked the bot or deleted the account.
+func IsChatUnavailableError(err error) bool {
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusForbidden {
+		return true
+	}
+	return isBadRequest(err, "chat not found")
+}
+
+func isBadRequest(err error, message string) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) &&
+		apiErr.Code == http.StatusBadRequest &&
+		strings.Contains(apiErr.Message, message)
+}
+
+// PlainText strips the markup of the parse mode from the text.
+func PlainText(text, parseMode string) string {
+	switch parseMode {
+	case tgbotapi.ModeHTML:
+		return html.UnescapeString(htmlTags.ReplaceAllString(text, ""))
+	case tgbotapi.ModeMarkdownV2, tgbotapi.ModeMarkdown:
+		text = markdownLinks.ReplaceAllString(text, "$1 ($2)")
+		// twice, since adjacent marks share the preceding character
+		text = markdownEntityMark.ReplaceAllString(text, "$1")
+		text = markdownEntityMark.ReplaceAllString(text, "$1")
+		return markdownEscape.ReplaceAllString(text, "$1")
+	default:
+		return text
+	}
+}
+
+// RenderedLen returns the length of the text as Telegram checks it against
+// the message and caption limits: in UTF-16 code units of the text without
+// the markup of the parse mode and the URLs of the links.
+func RenderedLen(text, parseMode string) int {
+	if parseMode == tgbotapi.ModeMarkdownV2 || parseMode == tgbotapi.ModeMarkdown {
+		text = markdownLinks.ReplaceAllString(text, "$1")
+	}
+	return markup.UTF16Len(PlainText(text, parseMode))
+}
+
+// SplitText splits the text into parts of at most size UTF-16 code units.
+// The text is cut at paragraph, line or word boundaries when possible and
+// never inside an escape sequence, an entity or a tag of the parse mode.
+func SplitText(text, parseMode string, size int) []string {
+	var parts []string
+
+	for markup.UTF16Len(text) > size {
+		cut := cutIndex(text, parseMode, size)
+		parts = append(parts, strings.TrimRight(text[:cut], " \n"))
+		text = strings.TrimLeft(text[cut:], " \n")
+	}
+	if text != "" || len(parts) == 0 {
+		parts = append(parts, text)
+	}
+
+	return parts
+}
+
+// cutIndex returns the byte index to cut the text at, so the first part is at
+// most size UTF-16 code units long.
+func cutIndex(text, parseMode string, size int) int {
+	var (
+		scanner   = newMarkupScanner(parseMode)
+		length    int
+		paragraph int
+		line      int
+		word      int
+		safe      int
+		hard      int
+	)
+
+	for i, r := range text {
+		if scanner.clean() && i > 0 {
+			safe = i
+			switch {
+			case strings.HasPrefix(text[i:], "\n\n"):
+				paragraph = i
+			case r == '\n':
+				line = i
+			case r == ' ':
+				word = i
+			}
+		}
+
+		length += markup.RuneLen(r)
+		if length > size {
+			hard = i
+			// don't separate an escaped character from its backslash, which
+			// is a single byte
+			if scanner.escaped && scanner.parseMode == tgbotapi.ModeMarkdownV2 {
+				hard--
+			}
+			break
+		}
+		scanner.next(r)
+	}
+
+	// prefer the natural boundaries unless they leave the part too short
+	end := hard
+	if end == 0 {
+		end = len(text)
+	}
+	for _, v := range []int{paragraph, line, word, safe} {
+		if v > end/2 {
+			return v
+		}
+	}
+	for _, v := range []int{paragraph, line, word, safe, hard} {
+		if v > 0 {
+			return v
+		}
+	}
+	// a single character longer than the size
+	_, width := firstRune(text)
+	return width
+}
+
+func firstRune(text string) (rune, int) {
+	for i, r := range text {
+		if i > 0 {
+			return r, i
+		}
+	}
+	return 0, len(text)
+}
+
+// markupScanner tracks whether the text scanned so far leaves an escape
+// sequence, an entity or a tag open.
+type markupScanner struct {
+	parseMode string
+
+	escaped  bool
+	previous rune
+	open     map[string]bool
+	link     int // 0 outside a link, 1 in its text, 2 in its URL
+	tags     int
+	inTag    bool
+}
+
+func newMarkupScanner(parseMode string) *markupScanner {
+	return &markupScanner{parseMode: parseMode, open: make(map[string]bool)}
+}
+
+func (s *markupScanner) clean() bool {
+	if s.escaped || s.inTag || s.tags > 0 || s.link > 0 {
+		return false
+	}
+	for _, open := range s.open {
+		if open {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *markupScanner) next(r rune) {
+	defer func() { s.previous = r }()
+
+	switch s.parseMode {
+	case tgbotapi.ModeMarkdownV2:
+		s.nextMarkdown(r)
+	case tgbotapi.ModeHTML:
+		s.nextHTML(r)
+	}
+}
+
+func (s *markupScanner) nextMarkdown(r rune) {
+	if s.escaped {
+		s.escaped = false
+		return
+	}
+
+	// only escapes and the closing parenthesis count in the URL of a link
+	if s.link == 2 {
+		switch r {
+		case '\\':
+			s.escaped = true
+		case ')':
+			s.link = 0
+		}
+		return
+	}
+
+	switch r {
+	case '\\':
+		s.escaped = true
+	case '`':
+		s.open["`"] = !s.open["`"]
+	case '*', '_', '~':
+		if !s.open["`"] {
+			s.open[string(r)] = !s.open[string(r)]
+		}
+	case '|':
+		if s.previous == '|' && !s.open["`"] {
+			s.open["||"] = !s.open["||"]
+		}
+	case '[':
+		if !s.open["`"] && s.link == 0 {
+			s.link = 1
+		}
+	case '(':
+		if s.link == 1 && s.previous == ']' {
+			s.link = 2
+		}
+	case ')':
+		if s.link == 2 {
+			s.link = 0
+		}
+	}
+}
+
+func (s *markupScanner) nextHTML(r rune) {
+	switch {
+	case r == '<':
+		s.inTag = true
+	case r == '>' && s.inTag:
+		s.inTag = false
+	case s.inTag && s.previous == '<':
+		if r == '/' {
+			s.tags--
+		} else {
+			s.tags++
+		}
+	case r == '&':
+		s.escaped = true
+	case r == ';':
+		s.escaped = false
+	}
+}
@@ -0,0 +1,179 @@
+package botkit
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSplitText(t *testing.T) {
+	link := `[a\_b](https://example.com/a_b?x=1)`
+
+	tests := []struct {
+		name      string
+		text      string
+		parseMode string
+		size      int
+		want      []string
+	}{
+		{
+			name: "short text",
+			text: "hello world",
+			size: 100,
+			want: []string{"hello world"},
+		},
+		{
+			name: "empty text",
+			text: "",
+			size: 100,
+			want: []string{""},
+		},
+		{
+			name: "word boundary",
+			text: "hello world foo",
+			size: 11,
+			want: []string{"hello world", "foo"},
+		},
+		{
+			name: "paragraph boundary",
+			text: "one\n\ntwo three",
+			size: 12,
+			want: []string{"one\n\ntwo", "three"},
+		},
+		{
+			name:      "links with entity marks in the URL",
+			text:      link + " " + link + " " + link,
+			parseMode: tgbotapi.ModeMarkdownV2,
+			size:      60,
+			want:      []string{link, link, link},
+		},
+		{
+			name:      "escapes are not split",
+			text:      `abc\.def`,
+			parseMode: tgbotapi.ModeMarkdownV2,
+			size:      4,
+			want:      []string{"abc", `\.de`, "f"},
+		},
+		{
+			name:      "consecutive escapes",
+			text:      `a\.b\.c\.d\.e`,
+			parseMode: tgbotapi.ModeMarkdownV2,
+			size:      4,
+			want:      []string{`a\.b`, `\.c`, `\.d`, `\.e`},
+		},
+		{
+			name:      "entities are not split",
+			text:      "*bold* text _italic text_",
+			parseMode: tgbotapi.ModeMarkdownV2,
+			size:      20,
+			want:      []string{"*bold* text", "_italic text_"},
+		},
+		{
+			name:      "tags are not split",
+			text:      "<b>bold</b> text tail",
+			parseMode: tgbotapi.ModeHTML,
+			size:      12,
+			want:      []string{"<b>bold</b>", "text tail"},
+		},
+		{
+			name: "astral characters count as two units",
+			text: "😀😀😀😀😀",
+			size: 4,
+			want: []string{"😀😀", "😀😀", "😀"},
+		},
+		{
+			name: "astral character at the cut",
+			text: "ab😀cd",
+			size: 3,
+			want: []string{"ab", "😀c", "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitText(tt.text, tt.parseMode, tt.size)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTextAfterLink(t *testing.T) {
+	text := `[docs](https://example.com/long_path?utm=feed) ` + strings.TrimSpace(strings.Repeat(`word\. `, 1000))
+
+	parts := SplitText(text, tgbotapi.ModeMarkdownV2, MessageMaxSize)
+	if len(parts) < 2 {
+		t.Fatalf("SplitText() returned %d parts, want at least 2", len(parts))
+	}
+	for _, part := range parts {
+		if !strings.HasSuffix(part, `\.`) {
+			t.Errorf("part is not cut at a word boundary: ...%q", part[len(part)-10:])
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var (
+		network   = &url.Error{Op: "Post", Err: errors.New("connection reset by peer")}
+		dial      = &url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+		flood     = &tgbotapi.Error{Code: 429, Message: "Too Many Requests", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 3}}
+		server    = &tgbotapi.Error{Code: 502, Message: "Bad Gateway"}
+		forbidden = &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+	)
+
+	tests := []struct {
+		name       string
+		err        error
+		idempotent bool
+		wantWait   time.Duration
+		wantRetry  bool
+	}{
+		{name: "network error", err: network, idempotent: true, wantRetry: true},
+		{name: "network error not idempotent", err: network, wantRetry: false},
+		{name: "dial error not idempotent", err: dial, wantRetry: true},
+		{name: "flood limit", err: flood, wantWait: 3 * time.Second, wantRetry: true},
+		{name: "server error", err: server, idempotent: true, wantRetry: true},
+		{name: "server error not idempotent", err: server, wantRetry: false},
+		{name: "forbidden", err: forbidden, idempotent: true, wantRetry: false},
+		{name: "no error", err: nil, idempotent: true, wantRetry: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, retry := retryAfter(tt.err, tt.idempotent)
+			if retry != tt.wantRetry || (retry && wait != tt.wantWait) {
+				t.Errorf("retryAfter() = %v, %v, want %v, %v", wait, retry, tt.wantWait, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestRenderedLen(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		parseMode string
+		want      int
+	}{
+		{name: "plain text", text: "a_b", want: 3},
+		{name: "markdown link", text: `*[a\_b](https://example.com/a_b)*`, parseMode: tgbotapi.ModeMarkdownV2, want: 3},
+		{name: "markdown escapes", text: `1\.5 \- 2`, parseMode: tgbotapi.ModeMarkdownV2, want: 7},
+		{name: "html", text: `<a href="https://example.com">a&amp;b</a>`, parseMode: tgbotapi.ModeHTML, want: 3},
+		{name: "astral characters", text: "😀 _x_", parseMode: tgbotapi.ModeMarkdownV2, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderedLen(tt.text, tt.parseMode); got != tt.want {
+				t.Errorf("RenderedLen() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
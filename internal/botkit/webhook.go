@@ -0,0 +1,130 @@
+package botkit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+	webhookShutdownTimeout = 10 * time.Second
+)
+
+var secretTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+type WebhookConfig struct {
+	// URL is the public address Telegram sends updates to, the reverse proxy
+	// must forward it to ListenAddr and Path.
+	URL        string
+	ListenAddr string
+	Path       string
+	// SecretToken is the token Telegram sends with the updates, so nobody
+	// else can send them. A random one is used if it is empty.
+	SecretToken string
+}
+
+// RunWebhook registers the webhook in Telegram, receives updates over HTTP and
+// removes the webhook when ctx is done.
+func (b *Bot) RunWebhook(ctx context.Context, cfg WebhookConfig) error {
+	if cfg.SecretToken == "" {
+		token := make([]byte, 32)
+		if _, err := rand.Read(token); err != nil {
+			return fmt.Errorf("generate webhook secret token: %w", err)
+		}
+		cfg.SecretToken = hex.EncodeToString(token)
+	}
+	if !secretTokenPattern.MatchString(cfg.SecretToken) {
+		return errors.New("webhook secret token must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+	}
+
+	if _, err := b.api.MakeRequest("setWebhook", tgbotapi.Params{
+		"url":          cfg.URL,
+		"secret_token": cfg.SecretToken,
+	}); err != nil {
+		return fmt.Errorf("set webhook: %w", err)
+	}
+	defer func() {
+		if _, err := b.api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			slog.With("error", err.Error()).Error("delete webhook")
+		}
+	}()
+
+	updates := make(chan tgbotapi.Update, b.api.Buffer)
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Path, webhookHandler(cfg.SecretToken, updates))
+
+	server := &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.With("error", err.Error()).Error("shutdown webhook server")
+		}
+	}()
+
+	serveCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		select {
+		case err := <-errCh:
+			cancel(fmt.Errorf("webhook server: %w", err))
+		case <-serveCtx.Done():
+		}
+	}()
+
+	if err := b.serve(serveCtx, updates); err != nil {
+		return context.Cause(serveCtx)
+	}
+	return nil
+}
+
+func webhookHandler(secretToken string, updates chan<- tgbotapi.Update) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(secretToken)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var update tgbotapi.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		select {
+		case updates <- update:
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+			// Telegram redelivers the update if the request fails
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}
+}
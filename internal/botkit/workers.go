@@ -0,0 +1,135 @@
+package botkit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	defaultWorkers      = 8
+	defaultQueueSize    = 100
+	defaultDrainTimeout = 30 * time.Second
+
+	updateTimeout = 5 * time.Minute
+)
+
+// workerPool handles updates concurrently. Updates of the same chat always go
+// to the same worker, so they are processed in the order they were received.
+type workerPool struct {
+	queues []chan tgbotapi.Update
+	handle func(ctx context.Context, update tgbotapi.Update)
+	wg     sync.WaitGroup
+}
+
+func newWorkerPool(workers, queueSize int, handle func(ctx context.Context, update tgbotapi.Update)) *workerPool {
+	workers = max(workers, 1)
+
+	p := &workerPool{
+		queues: make([]chan tgbotapi.Update, workers),
+		handle: handle,
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan tgbotapi.Update, max(queueSize, 0))
+	}
+
+	return p
+}
+
+func (p *workerPool) start(ctx context.Context) {
+	for _, queue := range p.queues {
+		p.wg.Add(1)
+		go func(queue <-chan tgbotapi.Update) {
+			defer p.wg.Done()
+			for update := range queue {
+				updateContext, updateCancel := context.WithTimeout(ctx, updateTimeout)
+				p.handle(updateContext, update)
+				updateCancel()
+			}
+		}(queue)
+	}
+}
+
+// submit blocks while the queue of the chat is full.
+func (p *workerPool) submit(ctx context.Context, update tgbotapi.Update) error {
+	queue := p.queues[updateKey(update)%uint64(len(p.queues))]
+
+	select {
+	case queue <- update:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain stops accepting updates and waits for the queued ones to be handled.
+// It reports false if the timeout expired first.
+func (p *workerPool) drain(timeout time.Duration) bool {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func updateKey(update tgbotapi.Update) uint64 {
+	switch {
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return uint64(update.CallbackQuery.Message.Chat.ID)
+	case update.CallbackQuery == nil && update.FromChat() != nil:
+		return uint64(update.FromChat().ID)
+	case update.SentFrom() != nil:
+		return uint64(update.SentFrom().ID)
+	default:
+		return uint64(update.UpdateID)
+	}
+}
+
+func (b *Bot) SetConcurrency(workers, queueSize int, drainTimeout time.Duration) {
+	b.workers = workers
+	b.queueSize = queueSize
+	b.drainTimeout = drainTimeout
+}
+
+func (b *Bot) serve(ctx context.Context, updates tgbotapi.UpdatesChannel) error {
+	// handlers keep running after ctx is done until the queues are drained
+	handlersCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+
+	pool := newWorkerPool(b.workers, b.queueSize, b.handleUpdate)
+	pool.start(handlersCtx)
+
+	defer func() {
+		if !pool.drain(b.drainTimeout) {
+			slog.Warn("drain updates: timeout exceeded, cancelling unfinished handlers")
+		}
+	}()
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := pool.submit(ctx, update); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"github.com/caarlos0/env/v10"
 	"time"
@@ -14,11 +15,22 @@ var (
 var cfg *Config
 
 type Config struct {
-	Project  Project
-	Settings Settings
-	Telegram Telegram
-	Database Database
-	OpenAI   OpenAI
+	Project    Project
+	Settings   Settings
+	Telegram   Telegram
+	Database   Database
+	OpenAI     OpenAI
+	Moderation Moderation
+	Scoring    Scoring
+	Posts      Posts
+	Publishers Publishers
+	Newsletter Newsletter
+	HTTP       HTTP
+	Admin      Admin
+	API        API
+	Dashboard  Dashboard
+	Webhooks   Webhooks
+	Feed       Feed
 }
 
 type Project struct {
@@ -32,12 +44,129 @@ type Project struct {
 type Settings struct {
 	FetchInterval        time.Duration `env:"FETCH_INTERVAL"`
 	NotificationInterval time.Duration `env:"NOTIFICATION_INTERVAL"`
+	DigestInterval       time.Duration `env:"DIGEST_INTERVAL" envDefault:"24h"`
+	FeedbackEnabled      bool          `env:"FEEDBACK_BUTTONS_ENABLED" envDefault:"false"`
 	FilterKeyword        []string
 }
 
 type Telegram struct {
-	BotToken  string `env:"TELEGRAM_BOT_TOKEN"`
-	ChannelID int64  `env:"TELEGRAM_CHANNEL_ID"`
+	BotToken          string        `env:"TELEGRAM_BOT_TOKEN"`
+	ChannelID         int64         `env:"TELEGRAM_CHANNEL_ID"`
+	ChannelSchedule   string        `env:"TELEGRAM_CHANNEL_SCHEDULE"`
+	AdminIDs          []int64       `env:"TELEGRAM_ADMIN_IDS" envSeparator:","`
+	AdminChatID       int64         `env:"TELEGRAM_ADMIN_CHAT_ID"`
+	ConversationStore string        `env:"TELEGRAM_CONVERSATION_STORE" envDefault:"memory"`
+	ConversationTTL   time.Duration `env:"TELEGRAM_CONVERSATION_TTL" envDefault:"10m"`
+	UpdatesMode       string        `env:"TELEGRAM_UPDATES_MODE" envDefault:"polling"`
+	Workers           int           `env:"TELEGRAM_WORKERS" envDefault:"8"`
+	WorkerQueueSize   int           `env:"TELEGRAM_WORKER_QUEUE_SIZE" envDefault:"100"`
+	DrainTimeout      time.Duration `env:"TELEGRAM_DRAIN_TIMEOUT" envDefault:"30s"`
+	Webhook           Webhook
+}
+
+type Webhook struct {
+	URL         string `env:"TELEGRAM_WEBHOOK_URL"`
+	ListenAddr  string `env:"TELEGRAM_WEBHOOK_LISTEN_ADDR" envDefault:":8080"`
+	Path        string `env:"TELEGRAM_WEBHOOK_PATH" envDefault:"/telegram/webhook"`
+	SecretToken string `env:"TELEGRAM_WEBHOOK_SECRET_TOKEN"`
+}
+
+type Moderation struct {
+	Enabled bool  `env:"MODERATION_ENABLED" envDefault:"false"`
+	ChatID  int64 `env:"MODERATION_CHAT_ID"`
+}
+
+type Posts struct {
+	ImagesEnabled     bool   `env:"POST_IMAGES_ENABLED" envDefault:"false"`
+	LinkButtonEnabled bool   `env:"POST_LINK_BUTTON_ENABLED" envDefault:"false"`
+	DiscussURL        string `env:"POST_DISCUSS_URL"`
+}
+
+// Publishers are the incoming webhooks of the destinations the articles are
+// posted to in addition to the Telegram channel, and their posting schedules
+// in the format of TELEGRAM_CHANNEL_SCHEDULE. Without a schedule an article
+// is posted to them right after the channel.
+type Publishers struct {
+	SlackWebhookURL      string `env:"SLACK_WEBHOOK_URL"`
+	SlackSchedule        string `env:"SLACK_SCHEDULE"`
+	DiscordWebhookURL    string `env:"DISCORD_WEBHOOK_URL"`
+	DiscordSchedule      string `env:"DISCORD_SCHEDULE"`
+	MattermostWebhookURL string `env:"MATTERMOST_WEBHOOK_URL"`
+	MattermostSchedule   string `env:"MATTERMOST_SCHEDULE"`
+}
+
+type Newsletter struct {
+	Enabled    bool          `env:"NEWSLETTER_ENABLED" envDefault:"false"`
+	Recipients []string      `env:"NEWSLETTER_RECIPIENTS" envSeparator:","`
+	From       string        `env:"NEWSLETTER_FROM"`
+	Interval   time.Duration `env:"NEWSLETTER_INTERVAL" envDefault:"24h"`
+	Size       uint64        `env:"NEWSLETTER_SIZE" envDefault:"10"`
+	SMTP       SMTP
+}
+
+type SMTP struct {
+	Host     string `env:"SMTP_HOST"`
+	Port     int    `env:"SMTP_PORT" envDefault:"587"`
+	Username string `env:"SMTP_USERNAME"`
+	Password string `env:"SMTP_PASSWORD"`
+}
+
+// Feed is the RSS, Atom and JSON feed of the posted articles served by the
+// public HTTP server.
+type Feed struct {
+	Enabled     bool   `env:"FEED_ENABLED" envDefault:"false"`
+	Title       string `env:"FEED_TITLE" envDefault:"News"`
+	Description string `env:"FEED_DESCRIPTION" envDefault:"Curated articles with summaries"`
+	PageSize    uint64 `env:"FEED_PAGE_SIZE" envDefault:"50"`
+}
+
+// HTTP is the public HTTP server, e.g. for the newsletter unsubscribe links.
+type HTTP struct {
+	ListenAddr string `env:"HTTP_LISTEN_ADDR" envDefault:":8081"`
+	PublicURL  string `env:"HTTP_PUBLIC_URL" envDefault:"http://localhost:8081"`
+}
+
+// Admin is the HTTP server with the health, readiness, status and metrics
+// endpoints, the REST API and the dashboard.
+// It shouldn't be reachable from the internet.
+type Admin struct {
+	ListenAddr string `env:"ADMIN_LISTEN_ADDR" envDefault:":8082"`
+}
+
+// API is the REST API served by the admin HTTP server, it's enabled when the
+// tokens are set.
+type API struct {
+	Tokens []string `env:"API_TOKENS" envSeparator:","`
+}
+
+// Dashboard is the web UI served by the admin HTTP server. The users log in
+// with the password or as the admins of the bot with the Telegram Login
+// Widget, at least one of them must be enabled.
+type Dashboard struct {
+	Enabled       bool          `env:"DASHBOARD_ENABLED" envDefault:"false"`
+	Password      string        `env:"DASHBOARD_PASSWORD"`
+	TelegramLogin bool          `env:"DASHBOARD_TELEGRAM_LOGIN" envDefault:"false"`
+	SessionSecret string        `env:"DASHBOARD_SESSION_SECRET"`
+	SessionTTL    time.Duration `env:"DASHBOARD_SESSION_TTL" envDefault:"12h"`
+	SecureCookie  bool          `env:"DASHBOARD_SECURE_COOKIE" envDefault:"false"`
+}
+
+// Webhooks are the outbound webhooks notified about the events of the bot.
+type Webhooks struct {
+	URLs        []string      `env:"WEBHOOK_URLS" envSeparator:","`
+	Secret      string        `env:"WEBHOOK_SECRET"`
+	Events      []string      `env:"WEBHOOK_EVENTS" envSeparator:"," envDefault:"article.stored,article.posted,source.failed"`
+	MaxAttempts int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"5"`
+	RetryDelay  time.Duration `env:"WEBHOOK_RETRY_DELAY" envDefault:"1s"`
+}
+
+type Scoring struct {
+	Enabled         bool               `env:"SCORING_ENABLED" envDefault:"false"`
+	Candidates      uint64             `env:"SCORING_CANDIDATES" envDefault:"20"`
+	RecencyHalfLife time.Duration      `env:"SCORING_RECENCY_HALF_LIFE" envDefault:"6h"`
+	Keywords        map[string]float64 `env:"SCORING_KEYWORDS" envSeparator:"," envKeyValSeparator:":"`
+	Weights         map[string]float64 `env:"SCORING_WEIGHTS" envSeparator:"," envKeyValSeparator:":" envDefault:"recency:1,priority:1,keywords:1,relevance:1,duplicates:1,quality:1"`
+	RelevancePrompt string             `env:"SCORING_RELEVANCE_PROMPT"`
 }
 
 type Database struct {
@@ -53,6 +182,10 @@ type OpenAI struct {
 	Key    string `env:"OPENAI_API_KEY"`
 	Prompt string `env:"OPENAI_API_PROMPT"`
 	Model  string `env:"OPENAI_API_MODEL" envDefault:"gpt-3.5-turbo"`
+	// PromptPrice and CompletionPrice are the prices of 1000 tokens in
+	// dollars, they estimate the cost in the metrics.
+	PromptPrice     float64 `env:"OPENAI_PROMPT_PRICE" envDefault:"0.0015"`
+	CompletionPrice float64 `env:"OPENAI_COMPLETION_PRICE" envDefault:"0.002"`
 }
 
 func Get() Config {
@@ -72,6 +205,16 @@ func Read() error {
 		return fmt.Errorf("parse env: %w", err)
 	}
 
+	if cfg.Scoring.Enabled && cfg.Scoring.RecencyHalfLife <= 0 {
+		return fmt.Errorf("SCORING_RECENCY_HALF_LIFE must be positive, got %s", cfg.Scoring.RecencyHalfLife)
+	}
+	if cfg.Moderation.Enabled && cfg.Moderation.ChatID == 0 && cfg.Telegram.AdminChatID == 0 {
+		return errors.New("MODERATION_CHAT_ID or TELEGRAM_ADMIN_CHAT_ID must be set when moderation is enabled")
+	}
+	if len(cfg.Webhooks.URLs) > 0 && cfg.Webhooks.Secret == "" {
+		return errors.New("WEBHOOK_SECRET must be set when WEBHOOK_URLS is set")
+	}
+
 	cfg.Project.Version = version
 	cfg.Project.CommitHash = commitHash
 
@@ -0,0 +1,385 @@
+package dashboard
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sessionCookie = "dashboard_session"
+	csrfField     = "csrf"
+
+	// telegramLoginMaxAge is how long the data of the Telegram Login Widget
+	// is accepted after the user authorized.
+	telegramLoginMaxAge = 24 * time.Hour
+
+	// maxLoginFailures is the number of wrong passwords from a client within
+	// loginLockout after which its logins are refused until the lockout ends.
+	maxLoginFailures = 5
+	loginLockout     = 15 * time.Minute
+)
+
+var (
+	errInvalidSession  = errors.New("invalid session")
+	errInvalidLogin    = errors.New("invalid telegram login data")
+	errLoginExpired    = errors.New("telegram login data is expired")
+	errLoginNotAllowed = errors.New("user is not an admin")
+)
+
+// session is the logged in user stored in the signed session cookie.
+type session struct {
+	User    string `json:"user"`
+	UserID  int64  `json:"user_id,omitempty"`
+	Expires int64  `json:"expires"`
+}
+
+type sessionKey struct{}
+
+func sessionFromContext(ctx context.Context) session {
+	s, _ := ctx.Value(sessionKey{}).(session)
+	return s
+}
+
+type csrfKey struct{}
+
+func csrfFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(csrfKey{}).(string)
+	return token
+}
+
+// SetPasswordLogin enables the login with the password.
+func (d *Dashboard) SetPasswordLogin(password string) {
+	hash := sha256.Sum256([]byte(password))
+	d.passwordHash = hash[:]
+}
+
+// SetTelegramLogin enables the login with the Telegram Login Widget of the
+// bot for the admins. The domain of the dashboard must be linked to the bot
+// with the /setdomain command of @BotFather.
+func (d *Dashboard) SetTelegramLogin(botToken, botUsername string, adminIDs []int64) {
+	d.botToken = botToken
+	d.botUsername = botUsername
+	d.adminIDs = make(map[int64]struct{}, len(adminIDs))
+	for _, v := range adminIDs {
+		d.adminIDs[v] = struct{}{}
+	}
+}
+
+// SetSecureCookie marks the session cookie Secure also for the requests
+// without TLS, i.e. when the dashboard is served over HTTPS by a proxy which
+// terminates TLS.
+func (d *Dashboard) SetSecureCookie(secure bool) {
+	d.secureCookie = secure
+}
+
+func (d *Dashboard) passwordLogin() bool {
+	return d.passwordHash != nil
+}
+
+func (d *Dashboard) telegramLogin() bool {
+	return d.botUsername != ""
+}
+
+// requireLogin redirects to the login page without a valid session and
+// checks the CSRF token of the forms.
+func (d *Dashboard) requireLogin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookie)
+		if err != nil {
+			http.Redirect(w, r, LoginPath, http.StatusSeeOther)
+			return
+		}
+		s, err := d.verifySession(cookie.Value)
+		if err != nil {
+			http.Redirect(w, r, LoginPath, http.StatusSeeOther)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			expected := d.csrfToken(cookie.Value)
+			if subtle.ConstantTimeCompare([]byte(r.PostFormValue(csrfField)), []byte(expected)) != 1 {
+				http.Error(w, "invalid CSRF token", http.StatusForbidden)
+				return
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), sessionKey{}, s)
+		ctx = context.WithValue(ctx, csrfKey{}, d.csrfToken(cookie.Value))
+		next(w, r.WithContext(ctx))
+	}
+}
+
+func (d *Dashboard) login(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		d.render(w, r, "login.html", d.loginData(""))
+	case http.MethodPost:
+		client := clientIP(r)
+		if wait := d.loginFailures.locked(client, time.Now()); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			w.WriteHeader(http.StatusTooManyRequests)
+			d.render(w, r, "login.html", d.loginData("Too many failed logins, try again later."))
+			return
+		}
+
+		password := sha256.Sum256([]byte(r.PostFormValue("password")))
+		if !d.passwordLogin() || subtle.ConstantTimeCompare(password[:], d.passwordHash) != 1 {
+			d.loginFailures.add(client, time.Now())
+			w.WriteHeader(http.StatusUnauthorized)
+			d.render(w, r, "login.html", d.loginData("Invalid password."))
+			return
+		}
+		d.loginFailures.reset(client)
+		d.startSession(w, r, session{User: "admin"})
+	default:
+		methodNotAllowed(w, "GET, POST")
+	}
+}
+
+// telegramAuth is the auth URL of the Telegram Login Widget, which redirects
+// to it with the user data signed with the bot token.
+func (d *Dashboard) telegramAuth(w http.ResponseWriter, r *http.Request) {
+	if !d.telegramLogin() {
+		http.NotFound(w, r)
+		return
+	}
+
+	s, err := d.verifyTelegramLogin(r.URL.Query(), time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		d.render(w, r, "login.html", d.loginData("Telegram login failed: "+err.Error()+"."))
+		return
+	}
+
+	d.startSession(w, r, s)
+}
+
+func (d *Dashboard) logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, "POST")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     Prefix,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   d.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, LoginPath, http.StatusSeeOther)
+}
+
+// clientIP returns the address of the client without the port, the admin
+// server is expected to be reached directly rather than through a proxy.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// loginLimiter counts the failed password logins of the clients, a client
+// is locked out after maxLoginFailures within loginLockout since the first.
+type loginLimiter struct {
+	mu      sync.Mutex
+	clients map[string]*loginAttempts
+}
+
+type loginAttempts struct {
+	failures int
+	since    time.Time
+}
+
+// locked returns how long the logins of the client are still refused.
+func (l *loginLimiter) locked(client string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	attempts, ok := l.clients[client]
+	if !ok || attempts.failures < maxLoginFailures {
+		return 0
+	}
+	return attempts.since.Add(loginLockout).Sub(now)
+}
+
+func (l *loginLimiter) add(client string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.clients == nil {
+		l.clients = make(map[string]*loginAttempts)
+	}
+
+	// the expired entries are dropped, so the map doesn't grow with every
+	// client which ever failed to log in
+	for k, v := range l.clients {
+		if now.Sub(v.since) > loginLockout {
+			delete(l.clients, k)
+		}
+	}
+
+	attempts, ok := l.clients[client]
+	if !ok {
+		attempts = &loginAttempts{since: now}
+		l.clients[client] = attempts
+	}
+	attempts.failures++
+}
+
+func (l *loginLimiter) reset(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.clients, client)
+}
+
+type loginData struct {
+	Error       string
+	Password    bool
+	BotUsername string
+	AuthURL     string
+}
+
+func (d *Dashboard) loginData(message string) loginData {
+	data := loginData{
+		Error:    message,
+		Password: d.passwordLogin(),
+		AuthURL:  TelegramAuthPath,
+	}
+	if d.telegramLogin() {
+		data.BotUsername = d.botUsername
+	}
+	return data
+}
+
+func (d *Dashboard) startSession(w http.ResponseWriter, r *http.Request, s session) {
+	expires := time.Now().Add(d.sessionTTL)
+	s.Expires = expires.Unix()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    d.signSession(s),
+		Path:     Prefix,
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   d.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, SourcesPath, http.StatusSeeOther)
+}
+
+func (d *Dashboard) secure(r *http.Request) bool {
+	return d.secureCookie || r.TLS != nil
+}
+
+// signSession encodes the session as "payload.signature", both base64
+// encoded, the signature is an HMAC of the payload with the session secret.
+func (d *Dashboard) signSession(s session) string {
+	payload, _ := json.Marshal(s)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(d.sign(encoded))
+}
+
+func (d *Dashboard) verifySession(value string) (session, error) {
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return session{}, errInvalidSession
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, d.sign(encoded)) {
+		return session{}, errInvalidSession
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return session{}, errInvalidSession
+	}
+	var s session
+	if err := json.Unmarshal(payload, &s); err != nil {
+		return session{}, errInvalidSession
+	}
+	if time.Now().Unix() > s.Expires {
+		return session{}, errInvalidSession
+	}
+
+	return s, nil
+}
+
+// csrfToken is bound to the session, so the forms can't be posted from
+// other sites.
+func (d *Dashboard) csrfToken(sessionValue string) string {
+	return hex.EncodeToString(d.sign(csrfField + ":" + sessionValue))
+}
+
+func (d *Dashboard) sign(value string) []byte {
+	mac := hmac.New(sha256.New, d.sessionSecret)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// verifyTelegramLogin checks the data of the Telegram Login Widget as
+// described in https://core.telegram.org/widgets/login#checking-authorization:
+// the hash is an HMAC-SHA256 of the sorted "key=value" lines with the SHA256
+// of the bot token as the key.
+func (d *Dashboard) verifyTelegramLogin(values url.Values, now time.Time) (session, error) {
+	hash := values.Get("hash")
+	if hash == "" {
+		return session{}, errInvalidLogin
+	}
+
+	lines := make([]string, 0, len(values))
+	for key := range values {
+		if key != "hash" {
+			lines = append(lines, key+"="+values.Get(key))
+		}
+	}
+	sort.Strings(lines)
+
+	secret := sha256.Sum256([]byte(d.botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(strings.ToLower(hash)), []byte(expected)) {
+		return session{}, errInvalidLogin
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return session{}, errInvalidLogin
+	}
+	if now.Sub(time.Unix(authDate, 0)) > telegramLoginMaxAge {
+		return session{}, errLoginExpired
+	}
+
+	id, err := strconv.ParseInt(values.Get("id"), 10, 64)
+	if err != nil {
+		return session{}, errInvalidLogin
+	}
+	if _, ok := d.adminIDs[id]; !ok {
+		return session{}, errLoginNotAllowed
+	}
+
+	user := strings.TrimSpace(values.Get("first_name") + " " + values.Get("last_name"))
+	if username := values.Get("username"); username != "" {
+		user = "@" + username
+	}
+
+	return session{User: user, UserID: id}, nil
+}
@@ -0,0 +1,210 @@
+package dashboard
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBotToken = "123456:test-token"
+
+func newTestDashboard() *Dashboard {
+	d := New(nil, nil, nil, nil, nil, nil, "test-secret", time.Hour)
+	d.SetTelegramLogin(testBotToken, "test_bot", []int64{42})
+	return d
+}
+
+func TestVerifySession(t *testing.T) {
+	d := newTestDashboard()
+
+	valid := d.signSession(session{User: "admin", Expires: time.Now().Add(time.Hour).Unix()})
+	expired := d.signSession(session{User: "admin", Expires: time.Now().Add(-time.Minute).Unix()})
+	payload, signature, _ := strings.Cut(valid, ".")
+	forged := New(nil, nil, nil, nil, nil, nil, "other-secret", time.Hour).
+		signSession(session{User: "admin", Expires: time.Now().Add(time.Hour).Unix()})
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "valid", value: valid},
+		{name: "expired", value: expired, wantErr: true},
+		{name: "tampered payload", value: strings.ToUpper(payload[:1]) + payload[1:] + "A." + signature, wantErr: true},
+		{name: "tampered signature", value: payload + "." + signature[:len(signature)-2] + "AA", wantErr: true},
+		{name: "signed with another secret", value: forged, wantErr: true},
+		{name: "no signature", value: payload, wantErr: true},
+		{name: "malformed", value: "not.a-session", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := d.verifySession(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifySession() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && s.User != "admin" {
+				t.Errorf("verifySession() user = %q, want %q", s.User, "admin")
+			}
+		})
+	}
+}
+
+// signTelegramLogin signs the values the way the Telegram Login Widget does.
+func signTelegramLogin(values url.Values, botToken string) url.Values {
+	lines := make([]string, 0, len(values))
+	for key := range values {
+		lines = append(lines, key+"="+values.Get(key))
+	}
+	sort.Strings(lines)
+
+	secret := sha256.Sum256([]byte(botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(lines, "\n")))
+
+	signed := url.Values{"hash": {hex.EncodeToString(mac.Sum(nil))}}
+	for key := range values {
+		signed.Set(key, values.Get(key))
+	}
+	return signed
+}
+
+func TestVerifyTelegramLogin(t *testing.T) {
+	d := newTestDashboard()
+	now := time.Now()
+
+	login := func(id int64, authDate time.Time) url.Values {
+		return url.Values{
+			"id":         {strconv.FormatInt(id, 10)},
+			"first_name": {"Ann"},
+			"username":   {"ann"},
+			"auth_date":  {strconv.FormatInt(authDate.Unix(), 10)},
+		}
+	}
+
+	tampered := signTelegramLogin(login(42, now), testBotToken)
+	tampered.Set("username", "mallory")
+
+	tests := []struct {
+		name    string
+		values  url.Values
+		wantErr error
+	}{
+		{name: "valid", values: signTelegramLogin(login(42, now), testBotToken)},
+		{name: "uppercase hash", values: func() url.Values {
+			v := signTelegramLogin(login(42, now), testBotToken)
+			v.Set("hash", strings.ToUpper(v.Get("hash")))
+			return v
+		}()},
+		{name: "tampered data", values: tampered, wantErr: errInvalidLogin},
+		{name: "signed with another token", values: signTelegramLogin(login(42, now), "654321:other"), wantErr: errInvalidLogin},
+		{name: "no hash", values: login(42, now), wantErr: errInvalidLogin},
+		{name: "expired", values: signTelegramLogin(login(42, now.Add(-25*time.Hour)), testBotToken), wantErr: errLoginExpired},
+		{name: "not an admin", values: signTelegramLogin(login(7, now), testBotToken), wantErr: errLoginNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := d.verifyTelegramLogin(tt.values, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("verifyTelegramLogin() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (s.User != "@ann" || s.UserID != 42) {
+				t.Errorf("verifyTelegramLogin() = %+v, want user @ann with ID 42", s)
+			}
+		})
+	}
+}
+
+func TestRequireLogin(t *testing.T) {
+	d := newTestDashboard()
+
+	cookie := d.signSession(session{User: "admin", Expires: time.Now().Add(time.Hour).Unix()})
+	other := d.signSession(session{User: "admin", Expires: time.Now().Add(2 * time.Hour).Unix()})
+
+	tests := []struct {
+		name       string
+		method     string
+		cookie     string
+		csrf       string
+		wantStatus int
+	}{
+		{name: "no session", method: http.MethodGet, wantStatus: http.StatusSeeOther},
+		{name: "invalid session", method: http.MethodGet, cookie: "invalid", wantStatus: http.StatusSeeOther},
+		{name: "get", method: http.MethodGet, cookie: cookie, wantStatus: http.StatusOK},
+		{name: "post with CSRF token", method: http.MethodPost, cookie: cookie, csrf: d.csrfToken(cookie), wantStatus: http.StatusOK},
+		{name: "post without CSRF token", method: http.MethodPost, cookie: cookie, wantStatus: http.StatusForbidden},
+		{name: "post with CSRF token of another session", method: http.MethodPost, cookie: cookie, csrf: d.csrfToken(other), wantStatus: http.StatusForbidden},
+	}
+
+	handler := d.requireLogin(func(w http.ResponseWriter, r *http.Request) {
+		if csrfFromContext(r.Context()) == "" {
+			t.Error("CSRF token is not in the context")
+		}
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			if tt.csrf != "" {
+				form.Set(csrfField, tt.csrf)
+			}
+			r := httptest.NewRequest(tt.method, SourcesPath, strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: sessionCookie, Value: tt.cookie})
+			}
+
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSessionCookieSecure(t *testing.T) {
+	tests := []struct {
+		name         string
+		secureCookie bool
+		tls          bool
+		want         bool
+	}{
+		{name: "plain HTTP", want: false},
+		{name: "TLS", tls: true, want: true},
+		{name: "behind a TLS-terminating proxy", secureCookie: true, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDashboard()
+			d.SetSecureCookie(tt.secureCookie)
+
+			target := "http://example.com" + LoginPath
+			if tt.tls {
+				target = "https://example.com" + LoginPath
+			}
+			w := httptest.NewRecorder()
+			d.startSession(w, httptest.NewRequest(http.MethodPost, target, nil), session{User: "admin"})
+
+			cookies := w.Result().Cookies()
+			if len(cookies) != 1 {
+				t.Fatalf("got %d cookies, want 1", len(cookies))
+			}
+			if cookies[0].Secure != tt.want {
+				t.Errorf("cookie Secure = %v, want %v", cookies[0].Secure, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"embed"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	Prefix           = "/dashboard/"
+	LoginPath        = Prefix + "login"
+	TelegramAuthPath = Prefix + "login/telegram"
+	LogoutPath       = Prefix + "logout"
+	SourcesPath      = Prefix + "sources"
+	ArticlesPath     = Prefix + "articles"
+	QueuePath        = Prefix + "queue"
+	HistoryPath      = Prefix + "history"
+	FiltersPath      = Prefix + "filters"
+	staticPath       = Prefix + "static/"
+
+	pageSize  = 25
+	queueSize = 50
+	timeFmt   = "2006-01-02 15:04"
+)
+
+//go:embed templates
+var templates embed.FS
+
+//go:embed static
+var static embed.FS
+
+var pages = parsePages("login.html", "sources.html", "articles.html", "queue.html", "history.html", "filters.html")
+
+type SourceProvider interface {
+	Sources(ctx context.Context) ([]*models.Source, error)
+}
+
+type ArticleProvider interface {
+	Latest(ctx context.Context, q models.ArticleQuery) ([]*models.Article, int, error)
+	Search(ctx context.Context, q models.ArticleQuery) ([]*models.Article, int, error)
+	Posted(ctx context.Context, q models.ArticleQuery) ([]*models.Article, int, error)
+	AllPending(ctx context.Context, limit uint64) ([]*models.Article, error)
+	AllApproved(ctx context.Context, limit uint64) ([]*models.Article, error)
+	Moderate(ctx context.Context, id int64, status string, moderatorID int64) error
+}
+
+type DeliveryProvider interface {
+	Latest(ctx context.Context, destination string, limit, offset uint64) ([]*models.Delivery, int, error)
+}
+
+type FilterStorage interface {
+	Keywords(ctx context.Context) ([]string, error)
+	SetKeywords(ctx context.Context, keywords []string) error
+}
+
+type SourceFetcher interface {
+	SourceHealth() []models.SourceHealth
+	FetchSource(ctx context.Context, id int64) (models.SourceHealth, error)
+}
+
+type PostingQueue interface {
+	QueueStats(ctx context.Context) (models.QueueStats, error)
+	Queue(ctx context.Context, limit uint64) ([]*models.Article, error)
+	ApproveAndPost(ctx context.Context, id int64, moderatorID int64) error
+}
+
+// Dashboard is the web UI of the admin HTTP server: the sources with their
+// health, the articles, the posting queue with moderation and the history,
+// and the filter keywords of the fetcher.
+type Dashboard struct {
+	sources    SourceProvider
+	articles   ArticleProvider
+	deliveries DeliveryProvider
+	filters    FilterStorage
+	fetcher    SourceFetcher
+	queue      PostingQueue
+
+	sessionSecret []byte
+	sessionTTL    time.Duration
+	secureCookie  bool
+
+	passwordHash  []byte
+	loginFailures loginLimiter
+
+	botToken    string
+	botUsername string
+	adminIDs    map[int64]struct{}
+}
+
+// New creates the dashboard. Without the session secret a random one is
+// used, so the sessions end on restart.
+func New(
+	sources SourceProvider,
+	articles ArticleProvider,
+	deliveries DeliveryProvider,
+	filters FilterStorage,
+	fetcher SourceFetcher,
+	queue PostingQueue,
+	sessionSecret string,
+	sessionTTL time.Duration,
+) *Dashboard {
+	d := &Dashboard{
+		sources:       sources,
+		articles:      articles,
+		deliveries:    deliveries,
+		filters:       filters,
+		fetcher:       fetcher,
+		queue:         queue,
+		sessionSecret: []byte(sessionSecret),
+		sessionTTL:    sessionTTL,
+	}
+
+	if sessionSecret == "" {
+		d.sessionSecret = make([]byte, 32)
+		if _, err := rand.Read(d.sessionSecret); err != nil {
+			panic(err)
+		}
+	}
+
+	return d
+}
+
+// Register registers the pages of the dashboard on the mux.
+func (d *Dashboard) Register(mux *http.ServeMux) {
+	staticFS, _ := fs.Sub(static, "static")
+
+	mux.Handle(staticPath, http.StripPrefix(staticPath, http.FileServer(http.FS(staticFS))))
+	mux.HandleFunc(LoginPath, d.login)
+	mux.HandleFunc(TelegramAuthPath, d.telegramAuth)
+	mux.HandleFunc(LogoutPath, d.logout)
+	mux.HandleFunc(SourcesPath, d.requireLogin(d.sourcesPage))
+	mux.HandleFunc(ArticlesPath, d.requireLogin(d.articlesPage))
+	mux.HandleFunc(QueuePath, d.requireLogin(d.queuePage))
+	mux.HandleFunc(HistoryPath, d.requireLogin(d.historyPage))
+	mux.HandleFunc(FiltersPath, d.requireLogin(d.filtersPage))
+	mux.HandleFunc(Prefix, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != Prefix {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, SourcesPath, http.StatusSeeOther)
+	})
+}
+
+// view is the data of the layout, Data is the data of the page.
+type view struct {
+	Path   string
+	User   string
+	CSRF   string
+	Notice string
+	Data   any
+}
+
+func (d *Dashboard) render(w http.ResponseWriter, r *http.Request, page string, data any) {
+	v := view{
+		Path:   r.URL.Path,
+		User:   sessionFromContext(r.Context()).User,
+		CSRF:   csrfFromContext(r.Context()),
+		Notice: r.URL.Query().Get("notice"),
+		Data:   data,
+	}
+
+	var buf bytes.Buffer
+	if err := pages[page].ExecuteTemplate(&buf, "layout", v); err != nil {
+		slog.With("error", err.Error()).ErrorContext(r.Context(), "render dashboard page", "page", page)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	_, _ = buf.WriteTo(w)
+}
+
+func (d *Dashboard) internalError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.With("error", err.Error()).ErrorContext(r.Context(), "dashboard request", "method", r.Method, "path", r.URL.Path)
+	http.Error(w, "internal error", http.StatusInternalServerError)
+}
+
+// redirect shows the page with the notice after a form is posted.
+func redirect(w http.ResponseWriter, r *http.Request, path, notice string) {
+	http.Redirect(w, r, path+"?"+url.Values{"notice": {notice}}.Encode(), http.StatusSeeOther)
+}
+
+// pageNumber parses the one-based page query parameter into a zero-based
+// page number.
+func pageNumber(r *http.Request) int {
+	number, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || number < 1 {
+		return 0
+	}
+	return number - 1
+}
+
+// pagination is the navigation between the pages of a list, the links keep
+// the other query parameters.
+type pagination struct {
+	botkit.Page
+	Prev string
+	Next string
+}
+
+func newPagination(r *http.Request, page botkit.Page) pagination {
+	link := func(number int) string {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(number+1))
+		query.Del("notice")
+		return r.URL.Path + "?" + query.Encode()
+	}
+
+	p := pagination{Page: page}
+	if page.HasPrev() {
+		p.Prev = link(page.Number - 1)
+	}
+	if page.HasNext() {
+		p.Next = link(page.Number + 1)
+	}
+	return p
+}
+
+// moderationForm is the data of the buttons of an article in the queue.
+type moderationForm struct {
+	CSRF     string
+	ID       int64
+	Moderate bool
+}
+
+func parsePages(names ...string) map[string]*template.Template {
+	funcs := template.FuncMap{
+		"formatTime": func(t time.Time) string {
+			if t.IsZero() {
+				return "—"
+			}
+			return t.UTC().Format(timeFmt)
+		},
+		"add": func(a, b int) int { return a + b },
+		"moderationForm": func(csrf string, id int64, moderate bool) moderationForm {
+			return moderationForm{CSRF: csrf, ID: id, Moderate: moderate}
+		},
+	}
+
+	result := make(map[string]*template.Template, len(names))
+	for _, name := range names {
+		result[name] = template.Must(template.New(name).Funcs(funcs).ParseFS(templates, "templates/layout.html", "templates/"+name))
+	}
+	return result
+}
@@ -0,0 +1,347 @@
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/notifier"
+	"github.com/to77e/news-fetching-bot/internal/repository"
+)
+
+const (
+	actionFetch   = "fetch"
+	actionApprove = "approve"
+	actionReject  = "reject"
+	actionPost    = "post"
+)
+
+type sourceRow struct {
+	Source *models.Source
+	Health models.SourceHealth
+	Status string
+}
+
+// sourcesPage lists the sources with the outcome of their latest fetches and
+// fetches a source on demand.
+func (d *Dashboard) sourcesPage(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		d.fetchSource(w, r)
+		return
+	default:
+		methodNotAllowed(w, "GET, POST")
+		return
+	}
+
+	sources, err := d.sources.Sources(r.Context())
+	if err != nil {
+		d.internalError(w, r, fmt.Errorf("list sources: %w", err))
+		return
+	}
+
+	health := make(map[int64]models.SourceHealth)
+	for _, v := range d.fetcher.SourceHealth() {
+		health[v.SourceID] = v
+	}
+
+	rows := make([]sourceRow, 0, len(sources))
+	for _, v := range sources {
+		row := sourceRow{Source: v, Status: "unknown"}
+		if h, ok := health[v.ID]; ok {
+			row.Health = h
+			row.Status = "ok"
+			if h.ConsecutiveFailures > 0 {
+				row.Status = "failing"
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	d.render(w, r, "sources.html", rows)
+}
+
+func (d *Dashboard) fetchSource(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PostFormValue("id"), 10, 64)
+	if err != nil || r.PostFormValue("action") != actionFetch {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	health, err := d.fetcher.FetchSource(r.Context(), id)
+	switch {
+	case errors.Is(err, repository.ErrorSourceNotFound):
+		redirect(w, r, SourcesPath, "Source not found.")
+	case err != nil:
+		redirect(w, r, SourcesPath, fmt.Sprintf("Fetch of %s failed: %s", health.Name, err))
+	default:
+		redirect(w, r, SourcesPath, fmt.Sprintf("Fetched %s: %d items in the feed.", health.Name, health.LastItems))
+	}
+}
+
+type articlesData struct {
+	Articles   []*models.Article
+	Sources    []*models.Source
+	Names      map[int64]string
+	Query      string
+	SourceID   int64
+	Total      int
+	Pagination pagination
+}
+
+// articlesPage browses the latest articles or searches them.
+func (d *Dashboard) articlesPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, "GET")
+		return
+	}
+
+	sources, err := d.sources.Sources(r.Context())
+	if err != nil {
+		d.internalError(w, r, fmt.Errorf("list sources: %w", err))
+		return
+	}
+
+	data := articlesData{
+		Sources: sources,
+		Names:   sourceNames(sources),
+		Query:   strings.TrimSpace(r.URL.Query().Get("q")),
+	}
+	data.SourceID, _ = strconv.ParseInt(r.URL.Query().Get("source"), 10, 64)
+
+	number := pageNumber(r)
+	list := d.articles.Latest
+	if data.Query != "" {
+		list = d.articles.Search
+	}
+	query := models.ArticleQuery{
+		Text:     data.Query,
+		SourceID: data.SourceID,
+		Limit:    pageSize,
+		Offset:   uint64(number * pageSize),
+	}
+	data.Articles, data.Total, err = list(r.Context(), query)
+	if err != nil {
+		d.internalError(w, r, fmt.Errorf("list articles: %w", err))
+		return
+	}
+	// a page past the end shows the last one
+	page := botkit.NewPage(number, pageSize, data.Total)
+	if offset := uint64(page.Offset()); offset != query.Offset {
+		query.Offset = offset
+		if data.Articles, data.Total, err = list(r.Context(), query); err != nil {
+			d.internalError(w, r, fmt.Errorf("list articles: %w", err))
+			return
+		}
+	}
+	data.Pagination = newPagination(r, page)
+
+	d.render(w, r, "articles.html", data)
+}
+
+type queueData struct {
+	Stats      models.QueueStats
+	Pending    []*models.Article
+	Approved   []*models.Article
+	Candidates []*models.Article
+	Names      map[int64]string
+}
+
+// queuePage shows the articles waiting for moderation, approved and selected
+// automatically, and moderates or posts them.
+func (d *Dashboard) queuePage(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		d.moderate(w, r)
+		return
+	default:
+		methodNotAllowed(w, "GET, POST")
+		return
+	}
+
+	var (
+		ctx  = r.Context()
+		data queueData
+		err  error
+	)
+	if data.Stats, err = d.queue.QueueStats(ctx); err != nil {
+		d.internalError(w, r, err)
+		return
+	}
+	if data.Pending, err = d.articles.AllPending(ctx, queueSize); err != nil {
+		d.internalError(w, r, fmt.Errorf("list pending articles: %w", err))
+		return
+	}
+	if data.Approved, err = d.articles.AllApproved(ctx, queueSize); err != nil {
+		d.internalError(w, r, fmt.Errorf("list approved articles: %w", err))
+		return
+	}
+	if data.Candidates, err = d.queue.Queue(ctx, queueSize); err != nil {
+		d.internalError(w, r, err)
+		return
+	}
+
+	sources, err := d.sources.Sources(ctx)
+	if err != nil {
+		d.internalError(w, r, fmt.Errorf("list sources: %w", err))
+		return
+	}
+	data.Names = sourceNames(sources)
+
+	d.render(w, r, "queue.html", data)
+}
+
+// moderate applies the action of the moderation buttons of the bot: posting
+// approves the article once it is posted, like the "Post now" button.
+func (d *Dashboard) moderate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PostFormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		ctx         = r.Context()
+		moderatorID = sessionFromContext(ctx).UserID
+		notice      string
+	)
+	switch r.PostFormValue("action") {
+	case actionApprove:
+		err = d.articles.Moderate(ctx, id, models.ModerationStatusApproved, moderatorID)
+		notice = fmt.Sprintf("Article %d approved.", id)
+	case actionReject:
+		err = d.articles.Moderate(ctx, id, models.ModerationStatusRejected, moderatorID)
+		notice = fmt.Sprintf("Article %d rejected.", id)
+	case actionPost:
+		err = d.queue.ApproveAndPost(ctx, id, moderatorID)
+		notice = fmt.Sprintf("Article %d posted.", id)
+	default:
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrorArticleNotFound):
+		redirect(w, r, QueuePath, fmt.Sprintf("Article %d not found.", id))
+	case errors.Is(err, notifier.ErrAlreadyPosted), errors.Is(err, notifier.ErrAlreadyRetracted):
+		redirect(w, r, QueuePath, fmt.Sprintf("Article %d: %s.", id, err))
+	case err != nil:
+		d.internalError(w, r, err)
+	default:
+		redirect(w, r, QueuePath, notice)
+	}
+}
+
+type historyData struct {
+	Articles   []*models.Article
+	Deliveries []*models.Delivery
+	Names      map[int64]string
+	Total      int
+	Pagination pagination
+}
+
+// historyPage lists the posted articles and the latest deliveries.
+func (d *Dashboard) historyPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, "GET")
+		return
+	}
+
+	var (
+		ctx    = r.Context()
+		number = pageNumber(r)
+		data   historyData
+		err    error
+	)
+	query := models.ArticleQuery{
+		Limit:  pageSize,
+		Offset: uint64(number * pageSize),
+	}
+	data.Articles, data.Total, err = d.articles.Posted(ctx, query)
+	if err != nil {
+		d.internalError(w, r, fmt.Errorf("list posted articles: %w", err))
+		return
+	}
+	// a page past the end shows the last one
+	page := botkit.NewPage(number, pageSize, data.Total)
+	if offset := uint64(page.Offset()); offset != query.Offset {
+		query.Offset = offset
+		if data.Articles, data.Total, err = d.articles.Posted(ctx, query); err != nil {
+			d.internalError(w, r, fmt.Errorf("list posted articles: %w", err))
+			return
+		}
+	}
+	data.Pagination = newPagination(r, page)
+
+	if data.Deliveries, _, err = d.deliveries.Latest(ctx, "", pageSize, 0); err != nil {
+		d.internalError(w, r, fmt.Errorf("list deliveries: %w", err))
+		return
+	}
+
+	sources, err := d.sources.Sources(ctx)
+	if err != nil {
+		d.internalError(w, r, fmt.Errorf("list sources: %w", err))
+		return
+	}
+	data.Names = sourceNames(sources)
+
+	d.render(w, r, "history.html", data)
+}
+
+// filtersPage edits the keywords of the items skipped by the fetcher, one
+// keyword per line. They apply from the next fetch.
+func (d *Dashboard) filtersPage(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		keywords, err := d.filters.Keywords(r.Context())
+		if err != nil {
+			d.internalError(w, r, err)
+			return
+		}
+		d.render(w, r, "filters.html", strings.Join(keywords, "\n"))
+	case http.MethodPost:
+		keywords := parseKeywords(r.PostFormValue("keywords"))
+		if err := d.filters.SetKeywords(r.Context(), keywords); err != nil {
+			d.internalError(w, r, err)
+			return
+		}
+		redirect(w, r, FiltersPath, fmt.Sprintf("%d filter keywords saved.", len(keywords)))
+	default:
+		methodNotAllowed(w, "GET, POST")
+	}
+}
+
+// parseKeywords returns the unique non-empty lines in lower case, as the
+// fetcher compares them with the lower case titles and categories.
+func parseKeywords(text string) []string {
+	seen := make(map[string]struct{})
+	keywords := make([]string, 0)
+	for _, v := range strings.Split(text, "\n") {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if _, ok := seen[v]; ok || v == "" {
+			continue
+		}
+		seen[v] = struct{}{}
+		keywords = append(keywords, v)
+	}
+	return keywords
+}
+
+func sourceNames(sources []*models.Source) map[int64]string {
+	names := make(map[int64]string, len(sources))
+	for _, v := range sources {
+		names[v.ID] = v.Name
+	}
+	return names
+}
+
+func methodNotAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,278 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// feed is a page of the feed independent of the format.
+type feed struct {
+	Title       string
+	Description string
+	Link        string
+	SelfURL     string
+	NextURL     string
+	Updated     time.Time
+	Items       []item
+}
+
+type item struct {
+	ID        string
+	Title     string
+	Link      string
+	Summary   string
+	Author    string
+	Tags      []string
+	ImageURL  string
+	Published time.Time
+	Updated   time.Time
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	AtomLinks     []atomLink `xml:"atom:link"`
+	Items         []rssItem  `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	Description string        `xml:"description,omitempty"`
+	Author      string        `xml:"dc:creator,omitempty"`
+	Categories  []string      `xml:"category"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+	PubDate     string        `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int    `xml:"length,attr"`
+}
+
+func (f feed) rss() ([]byte, error) {
+	channel := rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+		AtomLinks:   f.atomLinks(),
+	}
+	if !f.Updated.IsZero() {
+		channel.LastBuildDate = f.Updated.Format(time.RFC1123Z)
+	}
+
+	for _, v := range f.Items {
+		rssItem := rssItem{
+			Title:       v.Title,
+			Link:        v.Link,
+			GUID:        rssGUID{Value: v.ID},
+			Description: v.Summary,
+			Author:      v.Author,
+			Categories:  v.Tags,
+			PubDate:     v.Published.Format(time.RFC1123Z),
+		}
+		if v.ImageURL != "" {
+			// the length is unknown, which readers accept as 0
+			rssItem.Enclosure = &rssEnclosure{URL: v.ImageURL, Type: imageType(v.ImageURL)}
+		}
+		channel.Items = append(channel.Items, rssItem)
+	}
+
+	body, err := xml.MarshalIndent(rss{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: channel,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+type atom struct {
+	XMLName  xml.Name    `xml:"feed"`
+	NS       string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Summary    string         `xml:"summary,omitempty"`
+	Author     *atomAuthor    `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func (f feed) atom() ([]byte, error) {
+	// updated is required even for an empty feed
+	updated := f.Updated
+	if updated.IsZero() {
+		updated = time.Now().UTC()
+	}
+
+	doc := atom{
+		NS:       "http://www.w3.org/2005/Atom",
+		ID:       f.SelfURL,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  updated.Format(time.RFC3339),
+		Links:    append([]atomLink{{Href: f.Link, Rel: "alternate"}}, f.atomLinks()...),
+	}
+
+	for _, v := range f.Items {
+		entry := atomEntry{
+			ID:        v.ID,
+			Title:     v.Title,
+			Links:     []atomLink{{Href: v.Link, Rel: "alternate"}},
+			Summary:   v.Summary,
+			Published: v.Published.Format(time.RFC3339),
+			Updated:   v.Updated.Format(time.RFC3339),
+		}
+		if v.Author != "" {
+			entry.Author = &atomAuthor{Name: v.Author}
+		}
+		if v.ImageURL != "" {
+			entry.Links = append(entry.Links, atomLink{Href: v.ImageURL, Rel: "enclosure", Type: imageType(v.ImageURL)})
+		}
+		for _, tag := range v.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// atomLinks are the self and next links, which RSS readers understand in the
+// atom namespace as well.
+func (f feed) atomLinks() []atomLink {
+	links := []atomLink{{Href: f.SelfURL, Rel: "self"}}
+	if f.NextURL != "" {
+		links = append(links, atomLink{Href: f.NextURL, Rel: "next"})
+	}
+	return links
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url"`
+	NextURL     string         `json:"next_url,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+func (f feed) json() ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		Description: f.Description,
+		HomePageURL: f.Link,
+		FeedURL:     f.SelfURL,
+		NextURL:     f.NextURL,
+		Items:       []jsonFeedItem{},
+	}
+
+	for _, v := range f.Items {
+		jsonItem := jsonFeedItem{
+			ID:            v.ID,
+			URL:           v.Link,
+			Title:         v.Title,
+			ContentText:   v.Summary,
+			Image:         v.ImageURL,
+			DatePublished: v.Published.Format(time.RFC3339),
+			DateModified:  v.Updated.Format(time.RFC3339),
+			Tags:          v.Tags,
+		}
+		if v.Author != "" {
+			jsonItem.Authors = []jsonFeedAuthor{{Name: v.Author}}
+		}
+		doc.Items = append(doc.Items, jsonItem)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// imageType guesses the media type of the image by the extension of its URL.
+func imageType(imageURL string) string {
+	if u, err := url.Parse(imageURL); err == nil {
+		if mediaType := mime.TypeByExtension(path.Ext(u.Path)); strings.HasPrefix(mediaType, "image/") {
+			return mediaType
+		}
+	}
+	return "image/jpeg"
+}
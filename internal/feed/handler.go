@@ -0,0 +1,222 @@
+package feed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	RSSPath  = "/feed.rss"
+	AtomPath = "/feed.atom"
+	JSONPath = "/feed.json"
+
+	cacheControl = "public, max-age=300"
+)
+
+type ArticleProvider interface {
+	Posted(ctx context.Context, q models.ArticleQuery) ([]*models.Article, int, error)
+}
+
+// Handler serves the posted articles with their summaries as RSS 2.0, Atom
+// and JSON Feed depending on the path. The articles are filtered by the tag
+// and channel query parameters, the page parameter selects older articles.
+type Handler struct {
+	articles    ArticleProvider
+	title       string
+	description string
+	publicURL   string
+	pageSize    uint64
+}
+
+func NewHandler(articles ArticleProvider, title, description, publicURL string, pageSize uint64) *Handler {
+	return &Handler{
+		articles:    articles,
+		title:       title,
+		description: description,
+		publicURL:   strings.TrimRight(publicURL, "/"),
+		pageSize:    pageSize,
+	}
+}
+
+// Register registers the handler on the paths of the formats.
+func (h *Handler) Register(mux *http.ServeMux) {
+	for _, path := range []string{RSSPath, AtomPath, JSONPath} {
+		mux.Handle(path, h)
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	page, err := pageNumber(query.Get("page"))
+	if err != nil {
+		http.Error(w, "invalid page", http.StatusBadRequest)
+		return
+	}
+	var chatID int64
+	if channel := query.Get("channel"); channel != "" {
+		if chatID, err = strconv.ParseInt(channel, 10, 64); err != nil {
+			http.Error(w, "invalid channel", http.StatusBadRequest)
+			return
+		}
+	}
+	tag := strings.TrimSpace(query.Get("tag"))
+
+	articles, total, err := h.articles.Posted(r.Context(), models.ArticleQuery{
+		Tag:    tag,
+		ChatID: chatID,
+		Limit:  h.pageSize,
+		Offset: uint64(page-1) * h.pageSize,
+	})
+	if err != nil {
+		slog.With("error", err.Error()).ErrorContext(r.Context(), "list posted articles")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	doc := h.feed(r.URL.Path, tag, chatID, page, articles, total)
+
+	var (
+		body        []byte
+		contentType string
+	)
+	switch r.URL.Path {
+	case RSSPath:
+		body, err = doc.rss()
+		contentType = "application/rss+xml; charset=utf-8"
+	case AtomPath:
+		body, err = doc.atom()
+		contentType = "application/atom+xml; charset=utf-8"
+	case JSONPath:
+		body, err = doc.json()
+		contentType = "application/feed+json; charset=utf-8"
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		slog.With("error", err.Error()).ErrorContext(r.Context(), "render feed", "path", r.URL.Path)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	// the body depends on the edits and summaries of the articles as well,
+	// so the tag is its hash
+	hash := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(hash[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	if matchETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(body); err != nil {
+		slog.With("error", err.Error()).DebugContext(r.Context(), "write feed")
+	}
+}
+
+func (h *Handler) feed(path, tag string, chatID int64, page int, articles []*models.Article, total int) feed {
+	title := h.title
+	if tag != "" {
+		title += " #" + tag
+	}
+
+	doc := feed{
+		Title:       title,
+		Description: h.description,
+		Link:        h.publicURL,
+		SelfURL:     h.pageURL(path, tag, chatID, page),
+	}
+	if uint64(page)*h.pageSize < uint64(total) {
+		doc.NextURL = h.pageURL(path, tag, chatID, page+1)
+	}
+
+	for _, v := range articles {
+		summary := v.PostSummary
+		if summary == "" {
+			summary = v.Summary
+		}
+
+		updated := v.PostedDate
+		if v.EditedDate.After(updated) {
+			updated = v.EditedDate
+		}
+		if updated.After(doc.Updated) {
+			doc.Updated = updated
+		}
+
+		doc.Items = append(doc.Items, item{
+			ID:        h.publicURL + "/articles/" + strconv.FormatInt(v.ID, 10),
+			Title:     v.Title,
+			Link:      v.Link,
+			Summary:   summary,
+			Author:    v.Author,
+			Tags:      v.Tags,
+			ImageURL:  v.ImageURL,
+			Published: v.PostedDate,
+			Updated:   updated,
+		})
+	}
+
+	return doc
+}
+
+func (h *Handler) pageURL(path, tag string, chatID int64, page int) string {
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if chatID != 0 {
+		query.Set("channel", strconv.FormatInt(chatID, 10))
+	}
+	if page > 1 {
+		query.Set("page", strconv.Itoa(page))
+	}
+
+	if len(query) == 0 {
+		return h.publicURL + path
+	}
+	return h.publicURL + path + "?" + query.Encode()
+}
+
+func pageNumber(value string) (int, error) {
+	if value == "" {
+		return 1, nil
+	}
+
+	page, err := strconv.Atoi(value)
+	if err != nil || page < 1 {
+		return 0, strconv.ErrSyntax
+	}
+	return page, nil
+}
+
+func matchETag(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == etag || v == "*" {
+			return true
+		}
+	}
+	return false
+}
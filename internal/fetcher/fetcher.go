@@ -2,22 +2,41 @@ package fetcher
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"html"
 	"log/slog"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/to77e/news-fetching-bot/internal/metrics"
 	"github.com/to77e/news-fetching-bot/internal/models"
 	"github.com/to77e/news-fetching-bot/internal/source"
 )
 
 type ArticleRepository interface {
-	Store(ctx context.Context, article models.Article) error
+	Store(ctx context.Context, article models.Article) (int64, error)
 }
 
 type SourceRepository interface {
 	Sources(ctx context.Context) ([]*models.Source, error)
+	SourceByID(ctx context.Context, id int64) (*models.Source, error)
+}
+
+// FilterProvider provides the keywords of the items skipped in addition to
+// the configured ones, e.g. edited in the dashboard.
+type FilterProvider interface {
+	Keywords(ctx context.Context) ([]string, error)
+}
+
+// EventPublisher is notified about new articles and failing sources.
+type EventPublisher interface {
+	ArticleStored(ctx context.Context, article models.Article)
+	SourceFailed(ctx context.Context, source *models.Source, err error)
 }
 
 type Source interface {
@@ -32,6 +51,13 @@ type Fetcher struct {
 
 	fetchInterval time.Duration
 	filterKeyword []string
+
+	events  EventPublisher
+	filters FilterProvider
+
+	mu        sync.Mutex
+	lastFetch time.Time
+	health    map[int64]*models.SourceHealth
 }
 
 func New(
@@ -45,9 +71,18 @@ func New(
 		sources:       sources,
 		fetchInterval: fetchInterval,
 		filterKeyword: filterKeyword,
+		health:        make(map[int64]*models.SourceHealth),
 	}
 }
 
+func (f *Fetcher) SetEvents(events EventPublisher) {
+	f.events = events
+}
+
+func (f *Fetcher) SetFilters(filters FilterProvider) {
+	f.filters = filters
+}
+
 func (f *Fetcher) Start(ctx context.Context) error {
 	ticker := time.NewTicker(f.fetchInterval)
 	defer ticker.Stop()
@@ -73,55 +108,220 @@ func (f *Fetcher) Fetch(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("fetch sources: %w", err)
 	}
+	f.retainHealth(sources)
+
+	keywords := f.keywords(ctx)
 
 	var wg sync.WaitGroup
 	for _, v := range sources {
 		wg.Add(1)
 		rssSource := source.NewRSSSourceForModel(v)
 
-		go func(source Source) {
+		go func(model *models.Source, source Source) {
 			defer wg.Done()
-			items, err := source.Fetch(ctx)
-			if err != nil {
+			if err := f.fetchSource(ctx, model, source, keywords); err != nil {
 				slog.With("error", err.Error()).ErrorContext(ctx, "fetch source", "name", source.Name())
-				return
 			}
+		}(v, rssSource)
+	}
 
-			if err := f.processItems(ctx, source, items); err != nil {
-				slog.With("error", err.Error()).ErrorContext(ctx, "process items", "name", source.Name())
-				return
-			}
+	wg.Wait()
+
+	f.mu.Lock()
+	f.lastFetch = time.Now()
+	f.mu.Unlock()
 
-		}(rssSource)
+	return nil
+}
+
+// FetchSource fetches the source right away, regardless of the fetch
+// interval, and returns its health after the fetch.
+func (f *Fetcher) FetchSource(ctx context.Context, id int64) (models.SourceHealth, error) {
+	model, err := f.sources.SourceByID(ctx, id)
+	if err != nil {
+		return models.SourceHealth{}, fmt.Errorf("get source: %w", err)
+	}
+
+	err = f.fetchSource(ctx, model, source.NewRSSSourceForModel(model), f.keywords(ctx))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// the health is forgotten if the source is deleted meanwhile
+	health, ok := f.health[model.ID]
+	if !ok {
+		return models.SourceHealth{SourceID: model.ID, Name: model.Name}, err
+	}
+
+	return *health, err
+}
+
+func (f *Fetcher) fetchSource(ctx context.Context, model *models.Source, source Source, keywords []string) error {
+	start := time.Now()
+	items, err := source.Fetch(ctx)
+	metrics.FetchDuration.WithLabelValues(source.Name(), metrics.Result(err)).Observe(time.Since(start).Seconds())
+	f.recordFetch(model, len(items), err)
+	if err != nil {
+		if f.events != nil {
+			f.events.SourceFailed(ctx, model, err)
+		}
+		return err
+	}
+
+	if err := f.processItems(ctx, source, items, keywords); err != nil {
+		return fmt.Errorf("process items: %w", err)
 	}
 
-	wg.Wait()
 	return nil
 }
 
-func (f *Fetcher) processItems(ctx context.Context, source Source, items []models.Item) error {
+// LastFetch returns the time the last fetch of all sources completed.
+func (f *Fetcher) LastFetch() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.lastFetch
+}
+
+// SourceHealth returns the outcome of the latest fetches of the sources
+// ordered by their IDs.
+func (f *Fetcher) SourceHealth() []models.SourceHealth {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	health := make([]models.SourceHealth, 0, len(f.health))
+	for _, v := range f.health {
+		health = append(health, *v)
+	}
+	sort.Slice(health, func(i, j int) bool { return health[i].SourceID < health[j].SourceID })
+
+	return health
+}
+
+func (f *Fetcher) recordFetch(model *models.Source, items int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	health, ok := f.health[model.ID]
+	if !ok {
+		health = &models.SourceHealth{SourceID: model.ID}
+		f.health[model.ID] = health
+	}
+	health.Name = model.Name
+
+	if err != nil {
+		health.LastFailureDate = time.Now()
+		health.LastError = err.Error()
+		health.ConsecutiveFailures++
+		return
+	}
+
+	health.LastSuccessDate = time.Now()
+	health.LastItems = items
+	health.ConsecutiveFailures = 0
+}
+
+// retainHealth forgets the health of the deleted sources.
+func (f *Fetcher) retainHealth(sources []*models.Source) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := make(map[int64]struct{}, len(sources))
+	for _, v := range sources {
+		ids[v.ID] = struct{}{}
+	}
+	for id := range f.health {
+		if _, ok := ids[id]; !ok {
+			delete(f.health, id)
+		}
+	}
+}
+
+func (f *Fetcher) processItems(ctx context.Context, source Source, items []models.Item, keywords []string) error {
+	metrics.Items.WithLabelValues(source.Name(), metrics.ItemsFetched).Add(float64(len(items)))
+
 	for _, v := range items {
 		v.Date = v.Date.UTC()
 
-		if f.itemShouldBeSkipped(v) {
+		if itemShouldBeSkipped(v, keywords) {
+			metrics.Items.WithLabelValues(source.Name(), metrics.ItemsFiltered).Inc()
 			continue
 		}
-		if err := f.articles.Store(ctx, models.Article{
+
+		article := models.Article{
 			SourceID:      source.ID(),
 			Title:         v.Title,
 			Link:          v.Link,
 			Summary:       v.Summary,
+			Tags:          v.Categories,
+			Author:        v.Author,
+			ImageURL:      v.ImageURL,
 			PublishedDate: v.Date,
-		}); err != nil {
+			ContentHash:   contentHash(v),
+		}
+		id, err := f.articles.Store(ctx, article)
+		if err != nil {
 			return fmt.Errorf("store article.go: %w", err)
 		}
+
+		if id != 0 {
+			metrics.Items.WithLabelValues(source.Name(), metrics.ItemsStored).Inc()
+		}
+		if id != 0 && f.events != nil {
+			article.ID = id
+			f.events.ArticleStored(ctx, article)
+		}
 	}
 	return nil
 }
 
-func (f *Fetcher) itemShouldBeSkipped(item models.Item) bool {
+// contentHash identifies the content of the item which is posted, so the
+// repository can tell when a feed changes an already stored article. It
+// hashes the normalized text, so the markup, tracking parameters of links and
+// counters of comments or points in the summary don't count as changes, while
+// any other number, e.g. a corrected price or date, does.
+func contentHash(item models.Item) string {
+	summary := counters.ReplaceAllString(normalizeText(item.Summary), "")
+	hash := sha256.Sum256([]byte(normalizeText(item.Title) + "\n" + summary))
+	return hex.EncodeToString(hash[:])
+}
+
+var (
+	htmlTags  = regexp.MustCompile(`<[^>]*>`)
+	linkQuery = regexp.MustCompile(`(https?://[^\s?#]+)[?#]\S*`)
+	// counters matches the counters of aggregators in the normalized text,
+	// such as "points: 12" or "34 comments"
+	counters = regexp.MustCompile(`\b(?:points|comments|votes|likes|views):?\s*\d+|\b\d+\s+(?:points|comments|votes|likes|views)\b`)
+)
+
+// normalizeText returns the text without HTML tags and the query of links,
+// in lower case and with the whitespace collapsed.
+func normalizeText(text string) string {
+	text = html.UnescapeString(htmlTags.ReplaceAllString(text, " "))
+	text = linkQuery.ReplaceAllString(text, "$1")
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// keywords returns the configured keywords of the skipped items and the ones
+// of the filter provider. If the provider fails, the items are filtered by the
+// configured keywords only rather than not fetched at all.
+func (f *Fetcher) keywords(ctx context.Context) []string {
+	if f.filters == nil {
+		return f.filterKeyword
+	}
+
+	keywords, err := f.filters.Keywords(ctx)
+	if err != nil {
+		slog.With("error", err.Error()).ErrorContext(ctx, "get filter keywords")
+		return f.filterKeyword
+	}
+
+	return append(keywords, f.filterKeyword...)
+}
+
+func itemShouldBeSkipped(item models.Item, keywords []string) bool {
 	var categoryContainsKeyword bool
-	for _, keyword := range f.filterKeyword {
+	for _, keyword := range keywords {
 		for _, category := range item.Categories {
 			if strings.Contains(strings.ToLower(category), keyword) {
 				categoryContainsKeyword = true
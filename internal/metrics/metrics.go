@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	Path = "/metrics"
+
+	namespace = "newsbot"
+
+	ResultSuccess = "success"
+	ResultError   = "error"
+
+	ItemsFetched  = "fetched"
+	ItemsFiltered = "filtered"
+	ItemsStored   = "stored"
+
+	TokensPrompt     = "prompt"
+	TokensCompletion = "completion"
+)
+
+var (
+	FetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "fetcher",
+		Name:      "fetch_duration_seconds",
+		Help:      "Duration of the fetches of the sources by source and result.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"source", "result"})
+
+	Items = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "fetcher",
+		Name:      "items_total",
+		Help:      "Feed items fetched, skipped by the keyword filter and stored as new articles by source.",
+	}, []string{"source", "stage"})
+
+	Posts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "notifier",
+		Name:      "posts_total",
+		Help:      "Articles posted by destination and result.",
+	}, []string{"destination", "result"})
+
+	SummarizerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "summarizer",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the requests to the summarizer API by operation and result.",
+		Buckets:   []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80},
+	}, []string{"operation", "result"})
+
+	SummarizerTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "summarizer",
+		Name:      "tokens_total",
+		Help:      "Tokens used by the summarizer by operation and type.",
+	}, []string{"operation", "type"})
+
+	SummarizerCost = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "summarizer",
+		Name:      "cost_dollars_total",
+		Help:      "Estimated cost of the tokens used by the summarizer by operation.",
+	}, []string{"operation"})
+
+	BotCommands = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "bot",
+		Name:      "commands_total",
+		Help:      "Bot commands handled by command.",
+	}, []string{"command"})
+
+	BotCommandErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "bot",
+		Name:      "command_errors_total",
+		Help:      "Bot commands which failed with an internal error by command.",
+	}, []string{"command"})
+
+	BotCallbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "bot",
+		Name:      "callbacks_total",
+		Help:      "Inline button callbacks handled by namespace.",
+	}, []string{"namespace"})
+
+	BotCallbackErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "bot",
+		Name:      "callback_errors_total",
+		Help:      "Inline button callbacks which failed with an internal error by namespace.",
+	}, []string{"namespace"})
+)
+
+// Result returns the value of the result label for the error.
+func Result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+	return ResultSuccess
+}
+
+// BotObserver counts the commands and the callbacks handled by the bot and
+// their errors.
+type BotObserver struct{}
+
+func (BotObserver) CommandHandled(cmd string, err error) {
+	BotCommands.WithLabelValues(cmd).Inc()
+	if err != nil {
+		BotCommandErrors.WithLabelValues(cmd).Inc()
+	}
+}
+
+func (BotObserver) CallbackHandled(namespace string, err error) {
+	BotCallbacks.WithLabelValues(namespace).Inc()
+	if err != nil {
+		BotCallbackErrors.WithLabelValues(namespace).Inc()
+	}
+}
+
+// Handler serves the metrics of the default registry.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const collectTimeout = 5 * time.Second
+
+type QueueCounter interface {
+	QueueStats(ctx context.Context) (models.QueueStats, error)
+}
+
+// queueCollector counts the articles waiting to be posted on each scrape, so
+// the backlog is current even if the notifier is stuck.
+type queueCollector struct {
+	queue QueueCounter
+	desc  *prometheus.Desc
+}
+
+// RegisterQueue registers the gauge of the unposted articles by queue.
+func RegisterQueue(queue QueueCounter) {
+	prometheus.MustRegister(&queueCollector{
+		queue: queue,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "notifier", "queued_articles"),
+			"Articles waiting to be posted by queue.",
+			[]string{"queue"},
+			nil,
+		),
+	})
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	stats, err := c.queue.QueueStats(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(stats.NotPosted), "not_posted")
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(stats.Pending), "pending_moderation")
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(stats.Approved), "approved")
+}
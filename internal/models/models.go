@@ -8,6 +8,8 @@ type Item struct {
 	Link       string
 	Date       time.Time
 	Summary    string
+	Author     string
+	ImageURL   string
 	SourceName string
 }
 
@@ -15,16 +17,224 @@ type Source struct {
 	ID          int64
 	Name        string
 	URL         string
+	Priority    int
 	CreatedDate time.Time
 }
 
+// SourceHealth is the outcome of the latest fetches of a source.
+type SourceHealth struct {
+	SourceID            int64
+	Name                string
+	LastSuccessDate     time.Time
+	LastFailureDate     time.Time
+	LastError           string
+	ConsecutiveFailures int
+	// LastItems is the number of items in the feed on the last successful fetch.
+	LastItems int
+}
+
 type Article struct {
 	ID            int64
 	SourceID      int64
 	Title         string
 	Link          string
 	Summary       string
+	Tags          []string
+	Author        string
+	ImageURL      string
 	PublishedDate time.Time
 	PostedDate    time.Time
 	CreatedDate   time.Time
+	// PostSummary is the summary posted with the article, generated by the
+	// summarizer and possibly edited by a moderator.
+	PostSummary      string
+	ModerationStatus string
+	ModeratedBy      int64
+	ModeratedDate    time.Time
+	// Score is the ranking of the article computed by the notifier when it
+	// was a candidate for posting, ScoreFactors explain it.
+	Score        float64
+	ScoreFactors []ScoreFactor
+	ScoredDate   time.Time
+	// ContentHash identifies the title and summary of the article, so the
+	// changes of already stored articles are noticed.
+	ContentHash        string
+	ContentUpdatedDate time.Time
+	EditedDate         time.Time
+	RetractedDate      time.Time
+}
+
+// ScoreFactor is the contribution of one scorer to the score of an article:
+// the value computed by the scorer multiplied by its weight.
+type ScoreFactor struct {
+	Name   string
+	Value  float64
+	Weight float64
+}
+
+const (
+	ModerationStatusNone     = "none"
+	ModerationStatusPending  = "pending"
+	ModerationStatusApproved = "approved"
+	ModerationStatusRejected = "rejected"
+)
+
+const (
+	ArticleStatusQueued    = "queued"
+	ArticleStatusPosted    = "posted"
+	ArticleStatusRetracted = "retracted"
+)
+
+// Status returns the state of the article: retracted, posted, queued for
+// posting or, while it is moderated, its moderation status.
+func (a *Article) Status() string {
+	switch {
+	case !a.RetractedDate.IsZero():
+		return ArticleStatusRetracted
+	case !a.PostedDate.IsZero():
+		return ArticleStatusPosted
+	case a.ModerationStatus == ModerationStatusNone || a.ModerationStatus == "":
+		return ArticleStatusQueued
+	default:
+		return a.ModerationStatus
+	}
+}
+
+const (
+	DestinationTelegram   = "telegram"
+	DestinationSlack      = "slack"
+	DestinationDiscord    = "discord"
+	DestinationMattermost = "mattermost"
+)
+
+const (
+	TemplateScopeChannel = "channel"
+	TemplateScopeSource  = "source"
+)
+
+// PostTemplate is a text/template used to render the posts of a channel or
+// of the articles of a source.
+type PostTemplate struct {
+	ID          int64
+	Scope       string
+	TargetID    int64
+	ParseMode   string
+	Body        string
+	UpdatedDate time.Time
+}
+
+// Delivery is a message with an article sent to a destination.
+type Delivery struct {
+	ID          int64
+	ArticleID   int64
+	Destination string
+	ChatID      int64
+	MessageID   int64
+	URL         string
+	CreatedDate time.Time
+	DeletedDate time.Time
+}
+
+// NewsletterRecipient is an email address receiving the newsletter. The token
+// identifies the recipient in the unsubscribe link.
+type NewsletterRecipient struct {
+	ID               int64
+	Email            string
+	Token            string
+	LastSentDate     time.Time
+	UnsubscribedDate time.Time
+	CreatedDate      time.Time
+}
+
+// WebhookDeadLetter is an event which couldn't be delivered to a webhook.
+type WebhookDeadLetter struct {
+	ID          int64
+	EventID     string
+	Event       string
+	URL         string
+	Payload     []byte
+	Attempts    int
+	LastError   string
+	CreatedDate time.Time
+}
+
+// QueueStats are the numbers of articles waiting to be posted: the ones
+// selected automatically, waiting for moderation and approved by moderators.
+type QueueStats struct {
+	NotPosted int
+	Pending   int
+	Approved  int
+}
+
+// SummarizerStatus is the state of the summarizer and the outcome of its
+// last requests.
+// NotifyStep is the state of one step of the notification round, such as
+// posting to the channel or delivering the subscriptions.
+type NotifyStep struct {
+	Name            string
+	LastSuccessDate time.Time
+	LastFailureDate time.Time
+	LastError       string
+}
+
+type SummarizerStatus struct {
+	Enabled         bool
+	Model           string
+	LastSuccessDate time.Time
+	LastFailureDate time.Time
+	LastError       string
+}
+
+// Votes are the reader feedback on an article or all articles of a source.
+type Votes struct {
+	Up   int
+	Down int
+}
+
+type SourceQuality struct {
+	Votes Votes
+	Score float64
+}
+
+// ArticleQuery filters articles by a full-text query, source, publication
+// date, tag and the chat they were delivered to. Zero values disable the
+// corresponding filter.
+type ArticleQuery struct {
+	Text     string
+	SourceID int64
+	From     time.Time
+	To       time.Time
+	Tag      string
+	ChatID   int64
+	Limit    uint64
+	Offset   uint64
+}
+
+const (
+	SubscriptionKindSource = "source"
+	SubscriptionKindTag    = "tag"
+	SubscriptionKindQuery  = "query"
+
+	DeliveryFrequencyInstant = "instant"
+	DeliveryFrequencyDigest  = "digest"
+)
+
+type Subscription struct {
+	ID          int64
+	ChatID      int64
+	Kind        string
+	Value       string
+	CreatedDate time.Time
+}
+
+type Subscriber struct {
+	ChatID         int64
+	Frequency      string
+	LastDigestDate time.Time
+}
+
+type SubscriptionDelivery struct {
+	ChatID     int64
+	Article    *Article
+	QueuedDate time.Time
 }
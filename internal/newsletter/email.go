@@ -0,0 +1,91 @@
+package newsletter
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// email is a multipart message with plain text and HTML alternatives.
+type email struct {
+	From           string
+	To             string
+	Subject        string
+	UnsubscribeURL string
+	Text           string
+	HTML           string
+	Date           time.Time
+}
+
+func composeMessage(m email) ([]byte, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("parse from address: %w", err)
+	}
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, fmt.Errorf("parse to address: %w", err)
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, alternative := range []struct {
+		contentType string
+		content     string
+	}{
+		// the last alternative is the preferred one
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {alternative.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		writer := quotedprintable.NewWriter(part)
+		if _, err := writer.Write([]byte(alternative.content)); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	messageID, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	_, domain, _ := strings.Cut(from.Address, "@")
+
+	var message bytes.Buffer
+	for _, header := range [][2]string{
+		{"From", from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", m.Subject)},
+		{"Date", m.Date.Format(time.RFC1123Z)},
+		{"Message-ID", "<" + messageID + "@" + domain + ">"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()},
+		// one-click unsubscribe of RFC 8058
+		{"List-Unsubscribe", "<" + m.UnsubscribeURL + ">"},
+		{"List-Unsubscribe-Post", "List-Unsubscribe=One-Click"},
+	} {
+		message.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+
+	return message.Bytes(), nil
+}
@@ -0,0 +1,267 @@
+package newsletter
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	// UnsubscribePath is the path of the unsubscribe handler on the public
+	// HTTP server.
+	UnsubscribePath = "/newsletter/unsubscribe"
+
+	checkInterval  = 10 * time.Minute
+	subjectFmt     = "Top articles of %s"
+	subjectDate    = "January 2, 2006"
+	summaryMaxSize = 500
+)
+
+//go:embed templates
+var templates embed.FS
+
+var (
+	textTemplate = template.Must(template.ParseFS(templates, "templates/newsletter.txt"))
+	htmlTemplate = htmltemplate.Must(htmltemplate.ParseFS(templates, "templates/newsletter.html"))
+
+	htmlTags = regexp.MustCompile(`<[^>]*>`)
+)
+
+type ArticleProvider interface {
+	TopPosted(ctx context.Context, since time.Time, limit uint64) ([]*models.Article, error)
+}
+
+type SourceProvider interface {
+	SourceByID(ctx context.Context, id int64) (*models.Source, error)
+}
+
+type RecipientStorage interface {
+	AddRecipient(ctx context.Context, email, token string) error
+	RetainRecipients(ctx context.Context, emails []string) error
+	DueRecipients(ctx context.Context, interval time.Duration) ([]*models.NewsletterRecipient, error)
+	MarkSent(ctx context.Context, id int64) error
+}
+
+type Mailer interface {
+	Send(ctx context.Context, from, to string, message []byte) error
+}
+
+// Newsletter emails the best articles posted to the channel with their
+// summaries to the recipients every interval.
+type Newsletter struct {
+	articles   ArticleProvider
+	sources    SourceProvider
+	recipients RecipientStorage
+	mailer     Mailer
+	from       string
+	publicURL  string
+	interval   time.Duration
+	size       uint64
+}
+
+func New(
+	articles ArticleProvider,
+	sources SourceProvider,
+	recipients RecipientStorage,
+	mailer Mailer,
+	from string,
+	publicURL string,
+	interval time.Duration,
+	size uint64,
+) *Newsletter {
+	return &Newsletter{
+		articles:   articles,
+		sources:    sources,
+		recipients: recipients,
+		mailer:     mailer,
+		from:       from,
+		publicURL:  strings.TrimRight(publicURL, "/"),
+		interval:   interval,
+		size:       size,
+	}
+}
+
+// SyncRecipients makes the emails the recipients of the newsletter. New ones
+// get an unsubscribe token, the ones who unsubscribed stay unsubscribed.
+func (n *Newsletter) SyncRecipients(ctx context.Context, emails []string) error {
+	for i, email := range emails {
+		emails[i] = strings.ToLower(strings.TrimSpace(email))
+
+		token, err := newToken()
+		if err != nil {
+			return fmt.Errorf("failed to generate token: %w", err)
+		}
+		if err := n.recipients.AddRecipient(ctx, emails[i], token); err != nil {
+			return err
+		}
+	}
+
+	return n.recipients.RetainRecipients(ctx, emails)
+}
+
+func (n *Newsletter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	n.send(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			n.send(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (n *Newsletter) send(ctx context.Context) {
+	if err := n.SendDue(ctx); err != nil {
+		slog.With("error", err.Error()).ErrorContext(ctx, "send newsletter")
+	}
+}
+
+// SendDue sends the newsletter to the recipients who haven't received it for
+// the interval. Nothing is sent if no articles were posted.
+func (n *Newsletter) SendDue(ctx context.Context) error {
+	recipients, err := n.recipients.DueRecipients(ctx, n.interval)
+	if err != nil {
+		return fmt.Errorf("failed to get recipients: %w", err)
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	articles, err := n.articles.TopPosted(ctx, now.Add(-n.interval), n.size)
+	if err != nil {
+		return fmt.Errorf("failed to get articles: %w", err)
+	}
+	if len(articles) == 0 {
+		return nil
+	}
+
+	issue, err := n.issue(ctx, articles, now)
+	if err != nil {
+		return err
+	}
+
+	for _, recipient := range recipients {
+		if err := n.sendIssue(ctx, issue, recipient); err != nil {
+			// the recipient stays due and gets the newsletter on the next check
+			slog.With("error", err.Error()).ErrorContext(ctx, "send newsletter", "recipient", recipient.ID)
+			continue
+		}
+
+		if err := n.recipients.MarkSent(ctx, recipient.ID); err != nil {
+			return fmt.Errorf("failed to mark newsletter as sent: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// issueData is the data of the newsletter templates.
+type issueData struct {
+	Title          string
+	Articles       []issueArticle
+	UnsubscribeURL string
+}
+
+type issueArticle struct {
+	Title     string
+	Link      string
+	Summary   string
+	Source    string
+	Published time.Time
+}
+
+func (n *Newsletter) issue(ctx context.Context, articles []*models.Article, now time.Time) (issueData, error) {
+	issue := issueData{Title: fmt.Sprintf(subjectFmt, now.Format(subjectDate))}
+
+	sourceNames := make(map[int64]string)
+	for _, article := range articles {
+		name, ok := sourceNames[article.SourceID]
+		if !ok {
+			source, err := n.sources.SourceByID(ctx, article.SourceID)
+			if err != nil {
+				return issueData{}, fmt.Errorf("failed to get source: %w", err)
+			}
+			name = source.Name
+			sourceNames[article.SourceID] = name
+		}
+
+		issue.Articles = append(issue.Articles, issueArticle{
+			Title:     article.Title,
+			Link:      article.Link,
+			Summary:   articleSummary(article),
+			Source:    name,
+			Published: article.PublishedDate,
+		})
+	}
+
+	return issue, nil
+}
+
+// articleSummary returns the summary generated for the post or, if the article
+// has none yet, the shortened plain text of its summary in the feed.
+func articleSummary(article *models.Article) string {
+	if article.PostSummary != "" {
+		return article.PostSummary
+	}
+
+	text := html.UnescapeString(htmlTags.ReplaceAllString(article.Summary, " "))
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > summaryMaxSize {
+		text = string(runes[:summaryMaxSize]) + "…"
+	}
+	return text
+}
+
+func (n *Newsletter) sendIssue(ctx context.Context, issue issueData, recipient *models.NewsletterRecipient) error {
+	issue.UnsubscribeURL = n.publicURL + UnsubscribePath + "?" + url.Values{"token": {recipient.Token}}.Encode()
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, issue); err != nil {
+		return fmt.Errorf("failed to render text: %w", err)
+	}
+	if err := htmlTemplate.Execute(&html, issue); err != nil {
+		return fmt.Errorf("failed to render html: %w", err)
+	}
+
+	message, err := composeMessage(email{
+		From:           n.from,
+		To:             recipient.Email,
+		Subject:        issue.Title,
+		UnsubscribeURL: issue.UnsubscribeURL,
+		Text:           text.String(),
+		HTML:           html.String(),
+		Date:           time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to compose message: %w", err)
+	}
+
+	return n.mailer.Send(ctx, n.from, recipient.Email, message)
+}
+
+func newToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
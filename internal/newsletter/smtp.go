@@ -0,0 +1,90 @@
+package newsletter
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer sends emails through an SMTP server, upgrading the connection
+// with STARTTLS when the server supports it.
+type SMTPMailer struct {
+	host     string
+	addr     string
+	username string
+	password string
+}
+
+func NewSMTPMailer(host string, port int, username, password string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		username: username,
+		password: password,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, from, to string, message []byte) error {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return fmt.Errorf("parse from address: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("greeting: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(sender.Address); err != nil {
+		return fmt.Errorf("mail: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("rcpt: %w", err)
+	}
+
+	data, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+	if _, err := data.Write(message); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+	if err := data.Close(); err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,56 @@
+package newsletter
+
+import (
+	"context"
+	"errors"
+	htmltemplate "html/template"
+	"log/slog"
+	"net/http"
+)
+
+// ErrRecipientNotFound is returned by the Unsubscriber when no recipient has
+// the token.
+var ErrRecipientNotFound = errors.New("newsletter recipient not found")
+
+var unsubscribeTemplate = htmltemplate.Must(htmltemplate.ParseFS(templates, "templates/unsubscribe.html"))
+
+type Unsubscriber interface {
+	Unsubscribe(ctx context.Context, token string) error
+}
+
+// UnsubscribeHandler asks to confirm unsubscribing on GET, so that link
+// scanners of mail services don't unsubscribe the recipients, and
+// unsubscribes on POST, which is also the one-click unsubscribe of mail
+// clients.
+func UnsubscribeHandler(recipients Unsubscriber) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.FormValue("token")
+
+		switch r.Method {
+		case http.MethodGet:
+			renderUnsubscribe(w, http.StatusOK, "confirm", token)
+		case http.MethodPost:
+			err := recipients.Unsubscribe(r.Context(), token)
+			switch {
+			case errors.Is(err, ErrRecipientNotFound):
+				renderUnsubscribe(w, http.StatusNotFound, "not_found", "")
+			case err != nil:
+				slog.With("error", err.Error()).ErrorContext(r.Context(), "unsubscribe from newsletter")
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			default:
+				renderUnsubscribe(w, http.StatusOK, "done", "")
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func renderUnsubscribe(w http.ResponseWriter, status int, state, token string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := unsubscribeTemplate.Execute(w, struct{ State, Token string }{state, token}); err != nil {
+		slog.With("error", err.Error()).Error("render unsubscribe page")
+	}
+}
@@ -0,0 +1,184 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	changedArticlesBatchSize = 10
+
+	// editInterval is the least time between the edits of a post, also
+	// after a failed edit
+	editInterval = 30 * time.Minute
+	// maxEditFailures is the number of failed edits after which the post is
+	// no longer edited
+	maxEditFailures = 5
+)
+
+// EditChangedArticles updates the posted messages of the articles whose title
+// or summary changed in the feed since they were posted.
+func (n *Notifier) EditChangedArticles(ctx context.Context) error {
+	articles, err := n.articles.AllChanged(ctx, time.Now().Add(-editInterval), maxEditFailures, changedArticlesBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to get changed articles: %w", err)
+	}
+
+	for _, article := range articles {
+		if err := n.editArticle(ctx, article); err != nil {
+			// the article stays changed and is edited again after the interval
+			slog.With("error", err.Error()).ErrorContext(ctx, "edit article", "article", article.ID)
+
+			if err := n.articles.MarkEditFailed(ctx, article.ID); err != nil {
+				return fmt.Errorf("failed to mark article edit as failed: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (n *Notifier) editArticle(ctx context.Context, article *models.Article) error {
+	summary, err := n.postSummary(ctx, article)
+	if err != nil {
+		return fmt.Errorf("failed to extract summary: %w", err)
+	}
+
+	data, err := n.postData(ctx, article, summary)
+	if err != nil {
+		return err
+	}
+
+	text, parseMode, err := n.renderPost(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	var votes models.Votes
+	if n.votes != nil {
+		if votes, err = n.votes.ArticleVotes(ctx, article.ID); err != nil {
+			return fmt.Errorf("failed to count votes: %w", err)
+		}
+	}
+	keyboard, hasKeyboard := n.postKeyboard(data, votes)
+	if !hasKeyboard {
+		// an edit without a keyboard removes the one the message has
+		keyboard = tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	}
+
+	deliveries, err := n.deliveries.DeliveriesByArticle(ctx, article.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		if delivery.Destination != models.DestinationTelegram || !delivery.DeletedDate.IsZero() {
+			continue
+		}
+
+		err := n.editMessage(ctx, delivery, text, parseMode, keyboard)
+		if botkit.IsMessageNotFoundError(err) {
+			err = n.deliveries.MarkDeleted(ctx, delivery.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to edit message %d: %w", delivery.MessageID, err)
+		}
+	}
+
+	if err := n.articles.MarkEdited(ctx, article.ID); err != nil {
+		return fmt.Errorf("failed to mark article as edited: %w", err)
+	}
+
+	return nil
+}
+
+// editMessage replaces the text of the posted message, or its caption if the
+// post was sent as a photo. Only the first part of a post split into several
+// messages is kept, since the other parts are not recorded.
+func (n *Notifier) editMessage(
+	ctx context.Context,
+	delivery *models.Delivery,
+	text, parseMode string,
+	keyboard tgbotapi.InlineKeyboardMarkup,
+) error {
+	editText := tgbotapi.NewEditMessageText(delivery.ChatID, int(delivery.MessageID), firstPart(text, parseMode, botkit.MessageMaxSize))
+	editText.ParseMode = parseMode
+	editText.ReplyMarkup = &keyboard
+
+	_, err := n.sender.Request(ctx, editText)
+	if isNoTextError(err) {
+		editCaption := tgbotapi.NewEditMessageCaption(delivery.ChatID, int(delivery.MessageID), firstPart(text, parseMode, captionMaxSize))
+		editCaption.ParseMode = parseMode
+		editCaption.ReplyMarkup = &keyboard
+
+		_, err = n.sender.Request(ctx, editCaption)
+	}
+	if botkit.IsNotModifiedError(err) {
+		return nil
+	}
+
+	return err
+}
+
+// RetractArticle deletes the posted messages of the article and keeps it
+// from being posted or edited again. It returns the number of deleted
+// messages.
+func (n *Notifier) RetractArticle(ctx context.Context, id int64) (int, error) {
+	article, err := n.articles.ArticleByID(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get article: %w", err)
+	}
+	if !article.RetractedDate.IsZero() {
+		return 0, ErrAlreadyRetracted
+	}
+
+	deliveries, err := n.deliveries.DeliveriesByArticle(ctx, article.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get deliveries: %w", err)
+	}
+
+	var deleted int
+	for _, delivery := range deliveries {
+		if delivery.Destination != models.DestinationTelegram || !delivery.DeletedDate.IsZero() {
+			continue
+		}
+
+		_, err := n.sender.Request(ctx, tgbotapi.NewDeleteMessage(delivery.ChatID, int(delivery.MessageID)))
+		if err != nil && !botkit.IsMessageNotFoundError(err) {
+			return deleted, fmt.Errorf("failed to delete message %d: %w", delivery.MessageID, err)
+		}
+
+		if err := n.deliveries.MarkDeleted(ctx, delivery.ID); err != nil {
+			return deleted, fmt.Errorf("failed to mark delivery as deleted: %w", err)
+		}
+		deleted++
+	}
+
+	if err := n.articles.MarkRetracted(ctx, article.ID); err != nil {
+		return deleted, fmt.Errorf("failed to mark article as retracted: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// firstPart returns the text if it fits into the limit once rendered, like
+// the posts sent as photos, and the first part of the split text otherwise.
+func firstPart(text, parseMode string, size int) string {
+	if botkit.RenderedLen(text, parseMode) <= size {
+		return text
+	}
+	return botkit.SplitText(text, parseMode, size)[0]
+}
+
+func isNoTextError(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && strings.Contains(apiErr.Message, "there is no text in the message to edit")
+}
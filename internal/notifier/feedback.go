@@ -0,0 +1,72 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	CallbackVote        = "vote"
+	CallbackVoteVersion = 1
+
+	VoteUp   = "up"
+	VoteDown = "down"
+)
+
+type VoteCounter interface {
+	ArticleVotes(ctx context.Context, articleID int64) (models.Votes, error)
+}
+
+// SetFeedback enables the 👍/👎 buttons under the posted articles. The votes
+// are counted to keep the buttons up to date when the posts are edited.
+func (n *Notifier) SetFeedback(votes VoteCounter) {
+	n.votes = votes
+}
+
+// UpdateFeedbackKeyboard returns the keyboard of a posted message with the
+// vote buttons showing the current number of votes. Other buttons of the
+// keyboard are kept.
+func UpdateFeedbackKeyboard(keyboard *tgbotapi.InlineKeyboardMarkup, articleID int64, votes models.Votes) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if keyboard != nil {
+		for _, row := range keyboard.InlineKeyboard {
+			if !isFeedbackRow(row) {
+				rows = append(rows, row)
+			}
+		}
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(append(rows, feedbackRow(articleID, votes))...)
+}
+
+func feedbackRow(articleID int64, votes models.Votes) []tgbotapi.InlineKeyboardButton {
+	button := func(text string, count int, vote string) tgbotapi.InlineKeyboardButton {
+		if count > 0 {
+			text = fmt.Sprintf("%s %d", text, count)
+		}
+		data := botkit.NewCallbackData(CallbackVote, CallbackVoteVersion, strconv.FormatInt(articleID, 10), vote)
+		return tgbotapi.NewInlineKeyboardButtonData(text, data.MustEncode())
+	}
+
+	return tgbotapi.NewInlineKeyboardRow(
+		button("👍", votes.Up, VoteUp),
+		button("👎", votes.Down, VoteDown),
+	)
+}
+
+func isFeedbackRow(row []tgbotapi.InlineKeyboardButton) bool {
+	for _, v := range row {
+		if v.CallbackData == nil {
+			continue
+		}
+		if data, err := botkit.ParseCallbackData(*v.CallbackData); err == nil && data.Namespace == CallbackVote {
+			return true
+		}
+	}
+	return false
+}
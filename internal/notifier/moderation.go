@@ -0,0 +1,133 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/botkit/markup"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	CallbackModeration        = "moderation"
+	CallbackModerationVersion = 1
+
+	ModerationActionApprove = "approve"
+	ModerationActionReject  = "reject"
+	ModerationActionEdit    = "edit"
+	ModerationActionPost    = "post"
+)
+
+// SetModeration enables the moderation mode: candidate articles are sent to
+// the editors' chat first and only approved articles are posted.
+func (n *Notifier) SetModeration(chatID int64) {
+	n.moderationChatID = chatID
+}
+
+func (n *Notifier) selectAndModerateArticle(ctx context.Context) error {
+	allowed, err := n.canPost(ctx, models.DestinationTelegram)
+	if err != nil {
+		return err
+	}
+
+	if allowed {
+		approved, err := n.articles.AllApproved(ctx, 1)
+		if err != nil {
+			return fmt.Errorf("failed to get approved article: %w", err)
+		}
+		if len(approved) > 0 {
+			if err := n.postSelected(ctx, approved[0].ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	candidate, err := n.selectArticle(ctx)
+	if err != nil {
+		return err
+	}
+	if candidate == nil {
+		return nil
+	}
+
+	return n.moderate(ctx, candidate)
+}
+
+// ApproveAndPost posts the article right away and records that the moderator
+// approved it. The approval is recorded only once the article is posted, so
+// the notifier doesn't pick it from the approved articles in the meantime.
+func (n *Notifier) ApproveAndPost(ctx context.Context, id int64, moderatorID int64) error {
+	if err := n.post(ctx, id); err != nil {
+		return err
+	}
+
+	if err := n.articles.Moderate(ctx, id, models.ModerationStatusApproved, moderatorID); err != nil {
+		return fmt.Errorf("failed to record approval: %w", err)
+	}
+
+	return nil
+}
+
+// SendToModeration sends the article to the editors' chat, e.g. after its
+// summary was edited.
+func (n *Notifier) SendToModeration(ctx context.Context, id int64) error {
+	if n.moderationChatID == 0 {
+		return ErrModerationDisabled
+	}
+
+	article, err := n.articles.ArticleByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get article: %w", err)
+	}
+	if !article.PostedDate.IsZero() {
+		return ErrAlreadyPosted
+	}
+
+	return n.moderate(ctx, article)
+}
+
+func (n *Notifier) moderate(ctx context.Context, article *models.Article) error {
+	summary, err := n.postSummary(ctx, article)
+	if err != nil {
+		return fmt.Errorf("failed to extract summary: %w", err)
+	}
+
+	message := markup.NewBuilder().
+		Text("📝 Article ").Code(strconv.FormatInt(article.ID, 10)).Text("\n\n").
+		Bold(article.Title)
+	if summary != "" {
+		message.Text("\n\n" + summary)
+	}
+	message.Text("\n\n" + article.Link)
+
+	msg := tgbotapi.NewMessage(n.moderationChatID, message.MarkdownV2())
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	msg.ReplyMarkup = moderationKeyboard(article.ID)
+
+	if _, err := n.sender.SendText(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send article to moderation: %w", err)
+	}
+
+	return n.articles.MarkPending(ctx, article.ID)
+}
+
+func moderationKeyboard(articleID int64) tgbotapi.InlineKeyboardMarkup {
+	button := func(text, action string) tgbotapi.InlineKeyboardButton {
+		data := botkit.NewCallbackData(CallbackModeration, CallbackModerationVersion, action, strconv.FormatInt(articleID, 10))
+		return tgbotapi.NewInlineKeyboardButtonData(text, data.MustEncode())
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			button("✅ Approve", ModerationActionApprove),
+			button("❌ Reject", ModerationActionReject),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			button("✏️ Edit summary", ModerationActionEdit),
+			button("🚀 Post now", ModerationActionPost),
+		),
+	)
+}
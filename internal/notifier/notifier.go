@@ -2,23 +2,55 @@ package notifier
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-shiori/go-readability"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/to77e/news-fetching-bot/internal/botkit/markup"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
 	"github.com/to77e/news-fetching-bot/internal/models"
 )
 
+var (
+	ErrAlreadyPosted      = errors.New("article is already posted")
+	ErrModerationDisabled = errors.New("moderation is disabled")
+	ErrAlreadyRetracted   = errors.New("article is already retracted")
+)
+
 type ArticleProvider interface {
 	AllNotPosted(ctx context.Context, since time.Time, limit uint64) ([]*models.Article, error)
+	AllApproved(ctx context.Context, limit uint64) ([]*models.Article, error)
+	ArticleByID(ctx context.Context, id int64) (*models.Article, error)
+	SetPostSummary(ctx context.Context, id int64, summary string) error
+	MarkPending(ctx context.Context, id int64) error
 	MarkPosted(ctx context.Context, id int64) error
+	AllNotDelivered(ctx context.Context, destination string, since time.Time, limit uint64) ([]*models.Article, error)
+	AllChanged(ctx context.Context, editedBefore time.Time, maxFailures int, limit uint64) ([]*models.Article, error)
+	MarkEdited(ctx context.Context, id int64) error
+	MarkEditFailed(ctx context.Context, id int64) error
+	MarkRetracted(ctx context.Context, id int64) error
+	Moderate(ctx context.Context, id int64, status string, moderatorID int64) error
+	QueueStats(ctx context.Context, since time.Time) (models.QueueStats, error)
+}
+
+type DeliveryRecorder interface {
+	Store(ctx context.Context, delivery models.Delivery) error
+	Count(ctx context.Context, destination string, since time.Time) (int, error)
+	DeliveriesByArticle(ctx context.Context, articleID int64) ([]*models.Delivery, error)
+	MarkDeleted(ctx context.Context, id int64) error
+}
+
+// EventPublisher is notified about posted articles.
+type EventPublisher interface {
+	ArticlePosted(ctx context.Context, article *models.Article)
 }
 
 type Summarizer interface {
@@ -27,73 +59,286 @@ type Summarizer interface {
 
 type Notifier struct {
 	articles         ArticleProvider
+	deliveries       DeliveryRecorder
 	summarizer       Summarizer
 	bot              *tgbotapi.BotAPI
+	sender           *botkit.Sender
 	sendInterval     time.Duration
 	lookupTimeWindow time.Duration
 	channelID        int64
+
+	subscriptions  SubscriptionQueue
+	digestInterval time.Duration
+
+	moderationChatID int64
+
+	votes VoteCounter
+
+	ranker *Ranker
+	scores ScoreStorage
+
+	// schedules restrict posting by destination
+	schedules map[string]Schedule
+
+	templates TemplateProvider
+	sources   SourceProvider
+
+	// parsedTemplates are the validated templates by ID, kept until the
+	// template is updated
+	templatesMu     sync.Mutex
+	parsedTemplates map[int64]parsedTemplate
+
+	postOptions PostOptions
+
+	// publishers post the articles, the first one is the Telegram channel
+	publishers []Publisher
+
+	events EventPublisher
+
+	// postMu serializes posting, so an article selected by the notifier and
+	// posted on demand at the same time is posted once
+	postMu sync.Mutex
+
+	mu         sync.Mutex
+	lastNotify time.Time
+	steps      []models.NotifyStep
 }
 
 func New(
 	articles ArticleProvider,
+	deliveries DeliveryRecorder,
 	summarizer Summarizer,
 	bot *tgbotapi.BotAPI,
 	sendInterval time.Duration,
 	lookupTimeWindow time.Duration,
 	channelID int64,
 ) *Notifier {
-	return &Notifier{
+	n := &Notifier{
 		articles:         articles,
+		deliveries:       deliveries,
 		summarizer:       summarizer,
 		bot:              bot,
+		sender:           botkit.NewSender(bot),
 		sendInterval:     sendInterval,
 		lookupTimeWindow: lookupTimeWindow,
 		channelID:        channelID,
 	}
+	n.publishers = []Publisher{telegramPublisher{n: n}}
+
+	return n
+}
+
+func (n *Notifier) SetEvents(events EventPublisher) {
+	n.events = events
 }
 
 func (n *Notifier) Start(ctx context.Context) error {
 	ticker := time.NewTicker(n.sendInterval)
 	defer ticker.Stop()
 
-	if err := n.SelectAndSendArticle(ctx); err != nil {
-		slog.With("error", err.Error()).ErrorContext(ctx, "select and send article")
-	}
+	n.notify(ctx)
 
 	for {
 		select {
 		case <-ticker.C:
-			if err := n.SelectAndSendArticle(ctx); err != nil {
-				slog.With("error", err.Error()).ErrorContext(ctx, "select and send article")
-			}
+			n.notify(ctx)
 		case <-ctx.Done():
 			return ctx.Err()
 		}
 	}
 }
 
+func (n *Notifier) notify(ctx context.Context) {
+	steps := []struct {
+		name string
+		run  func(ctx context.Context) error
+	}{
+		{name: "select and send article", run: n.SelectAndSendArticle},
+		{name: "publish scheduled", run: n.PublishScheduled},
+		{name: "deliver subscriptions", run: n.DeliverSubscriptions},
+		{name: "edit changed articles", run: n.EditChangedArticles},
+	}
+
+	for i, step := range steps {
+		err := step.run(ctx)
+		if err != nil {
+			slog.With("error", err.Error()).ErrorContext(ctx, step.name)
+		}
+		n.recordStep(i, step.name, err)
+	}
+
+	n.mu.Lock()
+	n.lastNotify = time.Now()
+	n.mu.Unlock()
+}
+
+func (n *Notifier) recordStep(i int, name string, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.steps) <= i {
+		n.steps = append(n.steps, models.NotifyStep{Name: name})
+	}
+
+	step := &n.steps[i]
+	if err != nil {
+		step.LastFailureDate = time.Now()
+		step.LastError = err.Error()
+		return
+	}
+	step.LastSuccessDate = time.Now()
+	step.LastError = ""
+}
+
+// LastNotify returns the time of the last completed notification round,
+// whether or not its steps succeeded, the step errors are reported by Steps.
+func (n *Notifier) LastNotify() time.Time {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.lastNotify
+}
+
+// Steps returns the state of the steps of the notification round.
+func (n *Notifier) Steps() []models.NotifyStep {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return slices.Clone(n.steps)
+}
+
+// Queue returns the articles which are candidates for posting to the channel
+// in the order of AllNotPosted, the ranker may choose another one.
+func (n *Notifier) Queue(ctx context.Context, limit uint64) ([]*models.Article, error) {
+	articles, err := n.articles.AllNotPosted(ctx, time.Now().Add(-n.lookupTimeWindow), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queued articles: %w", err)
+	}
+
+	return articles, nil
+}
+
+// QueueStats counts the articles waiting to be posted to the channel.
+func (n *Notifier) QueueStats(ctx context.Context) (models.QueueStats, error) {
+	stats, err := n.articles.QueueStats(ctx, time.Now().Add(-n.lookupTimeWindow))
+	if err != nil {
+		return models.QueueStats{}, fmt.Errorf("failed to count queued articles: %w", err)
+	}
+
+	return stats, nil
+}
+
 func (n *Notifier) SelectAndSendArticle(ctx context.Context) error {
+	if n.moderationChatID != 0 {
+		return n.selectAndModerateArticle(ctx)
+	}
+
+	allowed, err := n.canPost(ctx, models.DestinationTelegram)
+	if err != nil || !allowed {
+		return err
+	}
+
 	//TODO: wrap in a transaction
-	topOneArticles, err := n.articles.AllNotPosted(ctx, time.Now().Add(-n.lookupTimeWindow), 1)
+	article, err := n.selectArticle(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get top one article: %w", err)
+		return err
 	}
 
-	if len(topOneArticles) == 0 {
+	if article == nil {
 		return nil
 	}
 
-	article := topOneArticles[0]
-	summary, err := n.extractSummary(ctx, article)
+	return n.postSelected(ctx, article.ID)
+}
+
+// PostArticle posts the article to the channel right away, regardless of its
+// moderation status.
+func (n *Notifier) PostArticle(ctx context.Context, id int64) error {
+	return n.post(ctx, id)
+}
+
+// postSelected posts the article selected by the notifier, which may have been
+// posted on demand in the meantime.
+func (n *Notifier) postSelected(ctx context.Context, id int64) error {
+	if err := n.post(ctx, id); err != nil && !errors.Is(err, ErrAlreadyPosted) && !errors.Is(err, ErrAlreadyRetracted) {
+		return err
+	}
+	return nil
+}
+
+// post posts the article unless it is already posted or retracted. The
+// article is read again while posting is locked, so it can't be posted twice.
+func (n *Notifier) post(ctx context.Context, id int64) error {
+	n.postMu.Lock()
+	defer n.postMu.Unlock()
+
+	article, err := n.articles.ArticleByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get article: %w", err)
+	}
+	if !article.PostedDate.IsZero() {
+		return ErrAlreadyPosted
+	}
+	if !article.RetractedDate.IsZero() {
+		return ErrAlreadyRetracted
+	}
+
+	summary, err := n.postSummary(ctx, article)
 	if err != nil {
 		return fmt.Errorf("failed to extract summary: %w", err)
 	}
 
-	if err := n.sendArticle(article, summary); err != nil {
+	data, err := n.postData(ctx, article, summary)
+	if err != nil {
+		return err
+	}
+
+	delivery, err := n.publish(ctx, n.publishers[0], data)
+	if err != nil {
 		return fmt.Errorf("failed to send article: %w", err)
 	}
 
-	return n.articles.MarkPosted(ctx, article.ID)
+	// the article is in the channel, so it is marked as posted before anything
+	// else can fail and make it posted again
+	if err := n.articles.MarkPosted(ctx, article.ID); err != nil {
+		return fmt.Errorf("failed to mark article as posted: %w", err)
+	}
+	article.PostedDate = time.Now().UTC()
+
+	n.storeDelivery(ctx, delivery)
+
+	if n.events != nil {
+		n.events.ArticlePosted(ctx, article)
+	}
+
+	// the other destinations are best effort and don't hold up the channel
+	if len(n.publishers) > 1 {
+		go n.publishSecondary(context.WithoutCancel(ctx), data)
+	}
+
+	return nil
+}
+
+// postSummary returns the stored summary of the article, generating and
+// storing it first if there is none yet.
+func (n *Notifier) postSummary(ctx context.Context, article *models.Article) (string, error) {
+	if article.PostSummary != "" {
+		return article.PostSummary, nil
+	}
+
+	summary, err := n.extractSummary(ctx, article)
+	if err != nil {
+		return "", err
+	}
+
+	if summary != "" {
+		if err := n.articles.SetPostSummary(ctx, article.ID, summary); err != nil {
+			return "", err
+		}
+		article.PostSummary = summary
+	}
+
+	return summary, nil
 }
 
 func (n *Notifier) extractSummary(ctx context.Context, article *models.Article) (string, error) {
@@ -121,28 +366,7 @@ func (n *Notifier) extractSummary(ctx context.Context, article *models.Article)
 		return "", fmt.Errorf("failed to summarize: %w", err)
 	}
 
-	return fmt.Sprintf("\n\n%s", summary), nil
-}
-
-func (n *Notifier) sendArticle(article *models.Article, summary string) error {
-	const (
-		messageFormat = "*%s*%s\n\n%s"
-	)
-
-	msg := tgbotapi.NewMessage(n.channelID, fmt.Sprintf(
-		messageFormat,
-		markup.EscapeForMarkdown(article.Title),
-		markup.EscapeForMarkdown(summary),
-		markup.EscapeForMarkdown(article.Link)),
-	)
-	msg.ParseMode = tgbotapi.ModeMarkdownV2
-
-	_, err := n.bot.Send(msg)
-	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
-	}
-
-	return nil
+	return summary, nil
 }
 
 var redundantNewLines = regexp.MustCompile(`\n{3,}`)
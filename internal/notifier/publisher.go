@@ -0,0 +1,102 @@
+package notifier
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/metrics"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+// Publisher posts articles to a destination. The delivery it returns
+// identifies the published message, if the destination allows to.
+type Publisher interface {
+	// Destination is the name of the destination in the deliveries.
+	Destination() string
+	Publish(ctx context.Context, post PostData) (models.Delivery, error)
+}
+
+// AddPublisher makes the notifier post the articles to the destination in
+// addition to the Telegram channel.
+func (n *Notifier) AddPublisher(publisher Publisher) {
+	n.publishers = append(n.publishers, publisher)
+}
+
+// secondaryPublishTimeout limits the time spent posting an article to the
+// destinations other than the channel, including their retries.
+const secondaryPublishTimeout = 5 * time.Minute
+
+func (n *Notifier) publish(ctx context.Context, publisher Publisher, post PostData) (models.Delivery, error) {
+	delivery, err := publisher.Publish(ctx, post)
+	metrics.Posts.WithLabelValues(publisher.Destination(), metrics.Result(err)).Inc()
+	if err != nil {
+		return models.Delivery{}, err
+	}
+
+	delivery.ArticleID = post.ID
+	delivery.Destination = publisher.Destination()
+
+	return delivery, nil
+}
+
+// publishSecondary posts the article to the destinations other than the
+// channel without a schedule, the failures are only logged. The ones with a
+// schedule are posted to by PublishScheduled.
+func (n *Notifier) publishSecondary(ctx context.Context, post PostData) {
+	ctx, cancel := context.WithTimeout(ctx, secondaryPublishTimeout)
+	defer cancel()
+
+	for _, publisher := range n.publishers[1:] {
+		if n.scheduled(publisher) {
+			continue
+		}
+
+		delivery, err := n.publish(ctx, publisher, post)
+		if err != nil {
+			slog.With("error", err.Error()).ErrorContext(ctx, "publish article",
+				"article", post.ID, "destination", publisher.Destination())
+			continue
+		}
+
+		n.storeDelivery(ctx, delivery)
+	}
+}
+
+// storeDelivery records the published message. The article is published
+// anyway, so a failure is only logged.
+func (n *Notifier) storeDelivery(ctx context.Context, delivery models.Delivery) {
+	if err := n.deliveries.Store(ctx, delivery); err != nil {
+		slog.With("error", err.Error()).ErrorContext(ctx, "store delivery",
+			"article", delivery.ArticleID, "destination", delivery.Destination)
+	}
+}
+
+// telegramPublisher posts to the channel of the notifier with its templates
+// and post options.
+type telegramPublisher struct {
+	n *Notifier
+}
+
+func (p telegramPublisher) Destination() string {
+	return models.DestinationTelegram
+}
+
+func (p telegramPublisher) Publish(ctx context.Context, post PostData) (models.Delivery, error) {
+	text, parseMode, err := p.n.renderPost(ctx, post)
+	if err != nil {
+		return models.Delivery{}, err
+	}
+
+	message, err := p.n.send(ctx, post, text, parseMode)
+	if err != nil {
+		return models.Delivery{}, err
+	}
+
+	return models.Delivery{
+		ChatID:    message.Chat.ID,
+		MessageID: int64(message.MessageID),
+		URL:       botkit.MessageLink(message.Chat, message.MessageID),
+	}, nil
+}
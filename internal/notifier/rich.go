@@ -0,0 +1,192 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"golang.org/x/net/html"
+)
+
+const (
+	captionMaxSize = 1024
+
+	pageFetchTimeout = 10 * time.Second
+	pageMaxSize      = 1 << 20
+)
+
+// PostOptions control how the posts look in the channel.
+type PostOptions struct {
+	// Images makes the notifier send the preview image of the article as
+	// a photo with the post as its caption.
+	Images bool
+	// LinkButton adds a "Read article" button with the article link.
+	LinkButton bool
+	// DiscussURL adds a "Discuss" button with the link, e.g. to the chat of
+	// the channel.
+	DiscussURL string
+}
+
+func (n *Notifier) SetPostOptions(options PostOptions) {
+	n.postOptions = options
+}
+
+// send sends the rendered post to the channel as a photo if the article has
+// an image and the post fits into a caption, and as a text message otherwise.
+func (n *Notifier) send(ctx context.Context, post PostData, text, parseMode string) (tgbotapi.Message, error) {
+	keyboard, hasKeyboard := n.postKeyboard(post, models.Votes{})
+
+	if n.postOptions.Images && botkit.RenderedLen(text, parseMode) <= captionMaxSize {
+		if imageURL := n.imageURL(ctx, post); imageURL != "" {
+			photo := tgbotapi.NewPhoto(n.channelID, tgbotapi.FileURL(imageURL))
+			photo.Caption = text
+			photo.ParseMode = parseMode
+			if hasKeyboard {
+				photo.ReplyMarkup = keyboard
+			}
+
+			message, err := n.sender.Send(ctx, photo)
+			if err == nil {
+				return message, nil
+			}
+			slog.With("error", err.Error()).WarnContext(ctx, "send photo, falling back to text", "article", post.ID)
+		}
+	}
+
+	msg := tgbotapi.NewMessage(n.channelID, text)
+	msg.ParseMode = parseMode
+	if hasKeyboard {
+		msg.ReplyMarkup = keyboard
+	}
+
+	// a long post is split into several messages, the first one represents
+	// the post
+	messages, err := n.sender.SendText(ctx, msg)
+	if err != nil {
+		return tgbotapi.Message{}, fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return messages[0], nil
+}
+
+func (n *Notifier) postKeyboard(post PostData, votes models.Votes) (tgbotapi.InlineKeyboardMarkup, bool) {
+	var (
+		rows  [][]tgbotapi.InlineKeyboardButton
+		links []tgbotapi.InlineKeyboardButton
+	)
+
+	if n.postOptions.LinkButton {
+		links = append(links, tgbotapi.NewInlineKeyboardButtonURL("📖 Read article", post.Link))
+	}
+	if n.postOptions.DiscussURL != "" {
+		links = append(links, tgbotapi.NewInlineKeyboardButtonURL("💬 Discuss", n.postOptions.DiscussURL))
+	}
+	if len(links) > 0 {
+		rows = append(rows, links)
+	}
+	if n.votes != nil {
+		rows = append(rows, feedbackRow(post.ID, votes))
+	}
+
+	if len(rows) == 0 {
+		return tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...), true
+}
+
+// imageURL returns the image of the article from the feed or the og:image of
+// the article page, or an empty string if there is none.
+func (n *Notifier) imageURL(ctx context.Context, post PostData) string {
+	if post.ImageURL != "" {
+		return post.ImageURL
+	}
+
+	imageURL, err := fetchOpenGraphImage(ctx, post.Link)
+	if err != nil {
+		slog.With("error", err.Error()).WarnContext(ctx, "fetch og:image", "article", post.ID)
+		return ""
+	}
+	return imageURL
+}
+
+func fetchOpenGraphImage(ctx context.Context, link string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, pageFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	imageURL := openGraphImage(io.LimitReader(resp.Body, pageMaxSize))
+	if imageURL == "" {
+		return "", nil
+	}
+
+	// og:image may be relative to the page
+	base, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(imageURL)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// openGraphImage returns the content of the og:image meta tag of the page
+// head.
+func openGraphImage(r io.Reader) string {
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "head" {
+				return ""
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if string(name) != "meta" || !hasAttr {
+				continue
+			}
+
+			var property, content string
+			for {
+				key, value, more := tokenizer.TagAttr()
+				switch string(key) {
+				case "property", "name":
+					property = strings.ToLower(string(value))
+				case "content":
+					content = string(value)
+				}
+				if !more {
+					break
+				}
+			}
+			if (property == "og:image" || property == "og:image:url") && content != "" {
+				return content
+			}
+		}
+	}
+}
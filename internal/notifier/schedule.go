@@ -0,0 +1,351 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// scheduleLookbackDays bounds the search for the start of a closed period.
+	scheduleLookbackDays = 8
+	// scheduledCandidates is the number of articles tried when posting to
+	// a destination with a schedule.
+	scheduledCandidates = 5
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// TimeRange is a range of the time of day given as offsets since midnight.
+// A range with From after To wraps around midnight.
+type TimeRange struct {
+	From time.Duration
+	To   time.Duration
+}
+
+func (r TimeRange) contains(offset time.Duration) bool {
+	if r.From <= r.To {
+		return offset >= r.From && offset < r.To
+	}
+	return offset >= r.From || offset < r.To
+}
+
+// Schedule restricts when articles are posted to a destination. The zero
+// value allows posting at any time.
+type Schedule struct {
+	Location *time.Location
+	Quiet    []TimeRange
+	// Days are the weekdays when posting is allowed, all days if empty.
+	Days      map[time.Weekday]bool
+	MaxPerDay int
+}
+
+// ParseSchedule parses a schedule in the form
+// "tz=Europe/Moscow;quiet=23:00-08:00,13:00-14:00;days=mon-fri,sun;max=10".
+// All the parts are optional.
+func ParseSchedule(spec string) (Schedule, error) {
+	schedule := Schedule{Location: time.UTC}
+
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(part, "=")
+		if !found {
+			return Schedule{}, fmt.Errorf("%q: %w", part, ErrInvalidSchedule)
+		}
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch strings.TrimSpace(name) {
+		case "tz":
+			schedule.Location, err = time.LoadLocation(value)
+		case "quiet":
+			schedule.Quiet, err = parseTimeRanges(value)
+		case "days":
+			schedule.Days, err = parseDays(value)
+		case "max":
+			schedule.MaxPerDay, err = strconv.Atoi(value)
+			if err == nil && schedule.MaxPerDay < 0 {
+				err = errors.New("negative maximum")
+			}
+		default:
+			err = errors.New("unknown option")
+		}
+		if err != nil {
+			return Schedule{}, fmt.Errorf("%q: %w: %s", part, ErrInvalidSchedule, err)
+		}
+	}
+
+	return schedule, nil
+}
+
+func parseTimeRanges(value string) ([]TimeRange, error) {
+	var ranges []TimeRange
+	for _, v := range strings.Split(value, ",") {
+		from, to, found := strings.Cut(strings.TrimSpace(v), "-")
+		if !found {
+			return nil, fmt.Errorf("time range %q", v)
+		}
+
+		var (
+			r   TimeRange
+			err error
+		)
+		if r.From, err = parseTimeOfDay(from); err != nil {
+			return nil, err
+		}
+		if r.To, err = parseTimeOfDay(to); err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("time %q", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func parseDays(value string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, v := range strings.Split(value, ",") {
+		from, to, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(v)), "-")
+		if !isRange {
+			to = from
+		}
+
+		first, ok := weekdays[from]
+		if !ok {
+			return nil, fmt.Errorf("weekday %q", from)
+		}
+		last, ok := weekdays[to]
+		if !ok {
+			return nil, fmt.Errorf("weekday %q", to)
+		}
+
+		for day := first; ; day = (day + 1) % 7 {
+			days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// Open reports whether the time is within the posting window.
+func (s Schedule) Open(t time.Time) bool {
+	t = t.In(s.location())
+
+	if len(s.Days) > 0 && !s.Days[t.Weekday()] {
+		return false
+	}
+
+	offset := t.Sub(startOfDay(t))
+	for _, r := range s.Quiet {
+		if r.contains(offset) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LastClosed returns the start of the most recent closed period before the
+// time, or the zero time if the schedule has not been closed recently.
+func (s Schedule) LastClosed(t time.Time) time.Time {
+	// the schedule opens or closes only at the boundaries, so it is open or
+	// closed for the whole period from one boundary to the next
+	boundaries := s.boundaries(t)
+
+	i := 0
+	for i < len(boundaries) && s.Open(boundaries[i]) {
+		i++
+	}
+	if i == len(boundaries) {
+		return time.Time{}
+	}
+
+	for i+1 < len(boundaries) && !s.Open(boundaries[i+1]) {
+		i++
+	}
+	return boundaries[i]
+}
+
+// boundaries returns the times of the last scheduleLookbackDays days up to
+// the time when the schedule may open or close, the latest first: the
+// midnights and the ends of the quiet ranges.
+func (s Schedule) boundaries(t time.Time) []time.Time {
+	t = t.In(s.location())
+
+	var boundaries []time.Time
+	for day := startOfDay(t); len(boundaries) == 0 || t.Sub(day) < scheduleLookbackDays*24*time.Hour; day = startOfDay(day.Add(-time.Hour)) {
+		candidates := []time.Time{day}
+		for _, r := range s.Quiet {
+			candidates = append(candidates, day.Add(r.From), day.Add(r.To))
+		}
+		for _, v := range candidates {
+			if !v.After(t) {
+				boundaries = append(boundaries, v)
+			}
+		}
+	}
+
+	slices.SortFunc(boundaries, func(a, b time.Time) int { return b.Compare(a) })
+	return slices.CompactFunc(boundaries, time.Time.Equal)
+}
+
+// StartOfDay returns the midnight of the day of the time in the schedule time
+// zone.
+func (s Schedule) StartOfDay(t time.Time) time.Time {
+	return startOfDay(t.In(s.location()))
+}
+
+func (s Schedule) location() *time.Location {
+	if s.Location == nil {
+		return time.UTC
+	}
+	return s.Location
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// SetSchedule restricts posting to the destination, e.g. the Telegram channel
+// or one of the publishers, to the schedule. The articles are posted to the
+// other destinations with a schedule once it allows, the best scored first.
+func (n *Notifier) SetSchedule(destination string, schedule Schedule) {
+	if n.schedules == nil {
+		n.schedules = make(map[string]Schedule)
+	}
+	n.schedules[destination] = schedule
+}
+
+// canPost reports whether the schedule of the destination allows posting now.
+func (n *Notifier) canPost(ctx context.Context, destination string) (bool, error) {
+	schedule, ok := n.schedules[destination]
+	if !ok {
+		return true, nil
+	}
+
+	now := time.Now()
+	if !schedule.Open(now) {
+		return false, nil
+	}
+
+	if schedule.MaxPerDay > 0 {
+		posted, err := n.deliveries.Count(ctx, destination, schedule.StartOfDay(now))
+		if err != nil {
+			return false, fmt.Errorf("failed to count posts: %w", err)
+		}
+		if posted >= schedule.MaxPerDay {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// lookupSince returns the time of the oldest article to consider for posting
+// to the destination. Articles published while the schedule was closed stay
+// candidates, so they are released once the window opens.
+func (n *Notifier) lookupSince(destination string) time.Time {
+	now := time.Now()
+	since := now.Add(-n.lookupTimeWindow)
+
+	schedule, ok := n.schedules[destination]
+	if !ok {
+		return since
+	}
+
+	if closed := schedule.LastClosed(now); !closed.IsZero() && closed.Add(-n.lookupTimeWindow).Before(since) {
+		since = closed.Add(-n.lookupTimeWindow)
+	}
+	return since
+}
+
+// scheduled reports whether the publisher posts on its own schedule rather
+// than right after the channel.
+func (n *Notifier) scheduled(publisher Publisher) bool {
+	_, ok := n.schedules[publisher.Destination()]
+	return ok
+}
+
+// PublishScheduled posts an article already in the channel to each of the
+// other destinations with a schedule which allows posting now, the best
+// scored article not posted there yet.
+func (n *Notifier) PublishScheduled(ctx context.Context) error {
+	for _, publisher := range n.publishers[1:] {
+		if !n.scheduled(publisher) {
+			continue
+		}
+
+		if err := n.publishScheduled(ctx, publisher); err != nil {
+			return fmt.Errorf("failed to publish to %s: %w", publisher.Destination(), err)
+		}
+	}
+
+	return nil
+}
+
+func (n *Notifier) publishScheduled(ctx context.Context, publisher Publisher) error {
+	allowed, err := n.canPost(ctx, publisher.Destination())
+	if err != nil || !allowed {
+		return err
+	}
+
+	articles, err := n.articles.AllNotDelivered(ctx, publisher.Destination(), n.lookupSince(publisher.Destination()), scheduledCandidates)
+	if err != nil {
+		return fmt.Errorf("failed to get articles: %w", err)
+	}
+
+	// an article which fails is retried on the next round, the next one is
+	// tried meanwhile so it doesn't hold up the queue
+	for _, article := range articles {
+		summary, err := n.postSummary(ctx, article)
+		if err != nil {
+			return fmt.Errorf("failed to extract summary: %w", err)
+		}
+
+		data, err := n.postData(ctx, article, summary)
+		if err != nil {
+			return err
+		}
+
+		delivery, err := n.publish(ctx, publisher, data)
+		if err != nil {
+			slog.With("error", err.Error()).ErrorContext(ctx, "publish article",
+				"article", article.ID, "destination", publisher.Destination())
+			continue
+		}
+
+		n.storeDelivery(ctx, delivery)
+		return nil
+	}
+
+	return nil
+}
@@ -0,0 +1,434 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	ScoreRecency    = "recency"
+	ScorePriority   = "priority"
+	ScoreKeywords   = "keywords"
+	ScoreRelevance  = "relevance"
+	ScoreDuplicates = "duplicates"
+	ScoreQuality    = "quality"
+
+	duplicateTitleSimilarity = 0.5
+	// duplicateWindow is how long a posted story keeps its duplicates among
+	// the candidates penalized, at most duplicatePostedLimit posts are checked
+	duplicateWindow      = 24 * time.Hour
+	duplicatePostedLimit = 200
+
+	// relevanceCacheSize bounds the number of remembered ratings, which
+	// are kept for relevanceTTL, or for relevanceFailureTTL if rating failed
+	relevanceCacheSize  = 1000
+	relevanceTTL        = 24 * time.Hour
+	relevanceFailureTTL = 30 * time.Minute
+)
+
+// Scorer rates one aspect of the candidates for posting, all at once so it
+// can look up what it needs in one go. It returns a value for every
+// candidate, the values are expected to be roughly within [0, 1].
+type Scorer interface {
+	Score(ctx context.Context, candidates []*models.Article) ([]float64, error)
+}
+
+type ScorerFunc func(ctx context.Context, candidates []*models.Article) ([]float64, error)
+
+func (f ScorerFunc) Score(ctx context.Context, candidates []*models.Article) ([]float64, error) {
+	return f(ctx, candidates)
+}
+
+// ArticleScorerFunc rates the candidates one by one without looking anything
+// up.
+type ArticleScorerFunc func(article *models.Article, candidates []*models.Article) float64
+
+func (f ArticleScorerFunc) Score(_ context.Context, candidates []*models.Article) ([]float64, error) {
+	values := make([]float64, 0, len(candidates))
+	for _, article := range candidates {
+		values = append(values, f(article, candidates))
+	}
+	return values, nil
+}
+
+type ScoreStorage interface {
+	SetScore(ctx context.Context, id int64, score float64, factors []models.ScoreFactor) error
+}
+
+type weightedScorer struct {
+	name   string
+	weight float64
+	scorer Scorer
+}
+
+// Ranker orders the candidates for posting by the weighted sum of the scores.
+type Ranker struct {
+	candidates uint64
+	scorers    []weightedScorer
+}
+
+// NewRanker returns a ranker looking at up to candidates articles. Scorers
+// without a weight or with a zero weight are skipped.
+func NewRanker(candidates uint64, weights map[string]float64, scorers map[string]Scorer) *Ranker {
+	r := &Ranker{candidates: max(candidates, 1)}
+
+	for name, scorer := range scorers {
+		if weight := weights[name]; weight != 0 {
+			r.scorers = append(r.scorers, weightedScorer{name: name, weight: weight, scorer: scorer})
+		}
+	}
+	sort.Slice(r.scorers, func(i, j int) bool {
+		return r.scorers[i].name < r.scorers[j].name
+	})
+
+	return r
+}
+
+// SetRanker makes the notifier pick the best scored article among the
+// candidates instead of the newest one and store the scores.
+func (n *Notifier) SetRanker(ranker *Ranker, scores ScoreStorage) {
+	n.ranker = ranker
+	n.scores = scores
+}
+
+// selectArticle returns the next article to post or nil if there is none.
+func (n *Notifier) selectArticle(ctx context.Context) (*models.Article, error) {
+	limit := uint64(1)
+	if n.ranker != nil {
+		limit = n.ranker.candidates
+	}
+
+	candidates, err := n.articles.AllNotPosted(ctx, n.lookupSince(models.DestinationTelegram), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candidate articles: %w", err)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	if n.ranker == nil {
+		return candidates[0], nil
+	}
+
+	n.ranker.score(ctx, candidates)
+
+	var best *models.Article
+	for _, article := range candidates {
+		if err := n.scores.SetScore(ctx, article.ID, article.Score, article.ScoreFactors); err != nil {
+			return nil, fmt.Errorf("failed to store score: %w", err)
+		}
+
+		if best == nil || article.Score > best.Score {
+			best = article
+		}
+	}
+
+	return best, nil
+}
+
+// score sets the scores of the candidates. A failing scorer does not prevent
+// posting, its factors are counted as zero, as well as values which are not
+// numbers.
+func (r *Ranker) score(ctx context.Context, candidates []*models.Article) {
+	for _, article := range candidates {
+		article.Score = 0
+		article.ScoreFactors = article.ScoreFactors[:0]
+	}
+
+	for _, v := range r.scorers {
+		values, err := v.scorer.Score(ctx, candidates)
+		if err == nil && len(values) != len(candidates) {
+			err = fmt.Errorf("got %d scores for %d candidates", len(values), len(candidates))
+		}
+		if err != nil {
+			slog.With("error", err.Error()).WarnContext(ctx, "score articles", "scorer", v.name)
+			values = make([]float64, len(candidates))
+		}
+
+		for i, article := range candidates {
+			value := values[i]
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				value = 0
+			}
+
+			article.Score += value * v.weight
+			article.ScoreFactors = append(article.ScoreFactors, models.ScoreFactor{
+				Name:   v.name,
+				Value:  value,
+				Weight: v.weight,
+			})
+		}
+	}
+
+	now := time.Now()
+	for _, article := range candidates {
+		article.ScoredDate = now
+	}
+}
+
+// RecencyScorer halves the score of an article every halfLife since its
+// publication. The half-life must be positive.
+func RecencyScorer(halfLife time.Duration) Scorer {
+	return ArticleScorerFunc(func(article *models.Article, _ []*models.Article) float64 {
+		age := max(time.Since(article.PublishedDate), 0)
+		return math.Pow(0.5, age.Hours()/halfLife.Hours())
+	})
+}
+
+type SourceProvider interface {
+	SourceByID(ctx context.Context, id int64) (*models.Source, error)
+}
+
+type SourceLister interface {
+	Sources(ctx context.Context) ([]*models.Source, error)
+}
+
+// SourcePriorityScorer scores an article by the priority of its source.
+func SourcePriorityScorer(sources SourceLister) Scorer {
+	return ScorerFunc(func(ctx context.Context, candidates []*models.Article) ([]float64, error) {
+		list, err := sources.Sources(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get sources: %w", err)
+		}
+
+		priorities := make(map[int64]int, len(list))
+		for _, v := range list {
+			priorities[v.ID] = v.Priority
+		}
+
+		values := make([]float64, 0, len(candidates))
+		for _, article := range candidates {
+			values = append(values, float64(priorities[article.SourceID])/100)
+		}
+		return values, nil
+	})
+}
+
+// KeywordScorer sums the boosts of the keywords found in the title, summary
+// or tags of an article.
+func KeywordScorer(boosts map[string]float64) Scorer {
+	return ArticleScorerFunc(func(article *models.Article, _ []*models.Article) float64 {
+		text := strings.ToLower(article.Title + " " + article.Summary + " " + strings.Join(article.Tags, " "))
+
+		var score float64
+		for keyword, boost := range boosts {
+			if strings.Contains(text, strings.ToLower(keyword)) {
+				score += boost
+			}
+		}
+		return score
+	})
+}
+
+type Rater interface {
+	Rate(ctx context.Context, text string) (float64, error)
+}
+
+// RelevanceScorer asks the language model to rate an article. The ratings are
+// remembered, so an article is rated once however long it stays a candidate;
+// an article which failed to be rated scores zero and is rated again later.
+func RelevanceScorer(rater Rater) Scorer {
+	ratings := newRatingCache(relevanceCacheSize)
+
+	return ScorerFunc(func(ctx context.Context, candidates []*models.Article) ([]float64, error) {
+		values := make([]float64, 0, len(candidates))
+		for _, article := range candidates {
+			if rating, ok := ratings.get(article.ID); ok {
+				values = append(values, rating)
+				continue
+			}
+
+			rating, err := rater.Rate(ctx, article.Title+"\n\n"+cleanText(article.Summary))
+			if err != nil {
+				slog.With("error", err.Error()).WarnContext(ctx, "rate article", "id", article.ID)
+				ratings.put(article.ID, 0, relevanceFailureTTL)
+				values = append(values, 0)
+				continue
+			}
+
+			ratings.put(article.ID, rating, relevanceTTL)
+			values = append(values, rating)
+		}
+		return values, nil
+	})
+}
+
+// ratingCache remembers the ratings of the articles for a while. When it is
+// full, the rating expiring first is forgotten.
+type ratingCache struct {
+	mu      sync.Mutex
+	size    int
+	ratings map[int64]cachedRating
+}
+
+type cachedRating struct {
+	value   float64
+	expires time.Time
+}
+
+func newRatingCache(size int) *ratingCache {
+	return &ratingCache{size: size, ratings: make(map[int64]cachedRating)}
+}
+
+func (c *ratingCache) get(id int64) (float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rating, ok := c.ratings[id]
+	if !ok || time.Now().After(rating.expires) {
+		return 0, false
+	}
+	return rating.value, true
+}
+
+func (c *ratingCache) put(id int64, value float64, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := c.ratings[id]; !ok && len(c.ratings) >= c.size {
+		var (
+			oldest   int64
+			earliest time.Time
+		)
+		for k, v := range c.ratings {
+			if now.After(v.expires) {
+				delete(c.ratings, k)
+				continue
+			}
+			if earliest.IsZero() || v.expires.Before(earliest) {
+				oldest, earliest = k, v.expires
+			}
+		}
+		if len(c.ratings) >= c.size {
+			delete(c.ratings, oldest)
+		}
+	}
+
+	c.ratings[id] = cachedRating{value: value, expires: now.Add(ttl)}
+}
+
+type PostedLister interface {
+	TopPosted(ctx context.Context, since time.Time, limit uint64) ([]*models.Article, error)
+}
+
+// DuplicateScorer rewards stories covered by several sources: the more
+// candidates have a similar title, the closer the score is to 1. Only the
+// first published candidate of the similar ones gets it, so the channel
+// doesn't post the same story from every source, and a candidate similar to
+// an article posted recently scores -1.
+func DuplicateScorer(posted PostedLister) Scorer {
+	return ScorerFunc(func(ctx context.Context, candidates []*models.Article) ([]float64, error) {
+		recent, err := posted.TopPosted(ctx, time.Now().Add(-duplicateWindow), duplicatePostedLimit)
+		if err != nil {
+			return nil, fmt.Errorf("get posted articles: %w", err)
+		}
+
+		postedWords := make([]map[string]struct{}, 0, len(recent))
+		for _, v := range recent {
+			postedWords = append(postedWords, titleWords(v.Title))
+		}
+		words := make([]map[string]struct{}, 0, len(candidates))
+		for _, v := range candidates {
+			words = append(words, titleWords(v.Title))
+		}
+
+		values := make([]float64, 0, len(candidates))
+		for i, article := range candidates {
+			if slices.ContainsFunc(postedWords, func(posted map[string]struct{}) bool {
+				return similarity(words[i], posted) >= duplicateTitleSimilarity
+			}) {
+				values = append(values, -1)
+				continue
+			}
+
+			cluster, first := 1, true
+			for j, v := range candidates {
+				if j == i || similarity(words[i], words[j]) < duplicateTitleSimilarity {
+					continue
+				}
+				cluster++
+				if publishedBefore(v, article) {
+					first = false
+				}
+			}
+
+			var value float64
+			if first {
+				value = 1 - 1/float64(cluster)
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	})
+}
+
+// publishedBefore reports whether a was published before b, the one stored
+// first if both were published at the same time.
+func publishedBefore(a, b *models.Article) bool {
+	if !a.PublishedDate.Equal(b.PublishedDate) {
+		return a.PublishedDate.Before(b.PublishedDate)
+	}
+	return a.ID < b.ID
+}
+
+type SourceQualityProvider interface {
+	SourceQuality(ctx context.Context) (map[int64]models.SourceQuality, error)
+}
+
+// SourceQualityScorer scores an article by the reader votes of its source
+// from -1 for disliked sources to 1 for liked ones.
+func SourceQualityScorer(qualities SourceQualityProvider) Scorer {
+	return ScorerFunc(func(ctx context.Context, candidates []*models.Article) ([]float64, error) {
+		quality, err := qualities.SourceQuality(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get source quality: %w", err)
+		}
+
+		values := make([]float64, 0, len(candidates))
+		for _, article := range candidates {
+			var value float64
+			if q, ok := quality[article.SourceID]; ok {
+				value = (q.Score - 0.5) * 2
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	})
+}
+
+func titleWords(title string) map[string]struct{} {
+	words := make(map[string]struct{})
+	for _, v := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(v)) > 2 {
+			words[v] = struct{}{}
+		}
+	}
+	return words
+}
+
+// similarity is the Jaccard index of two sets of words.
+func similarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	var common int
+	for v := range a {
+		if _, ok := b[v]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
@@ -0,0 +1,140 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/botkit/markup"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	instantDeliveryBatch = 20
+	digestMaxArticles    = 30
+)
+
+type SubscriptionQueue interface {
+	Enqueue(ctx context.Context, since time.Time) error
+	PendingInstant(ctx context.Context, limit uint64) ([]*models.SubscriptionDelivery, error)
+	DueDigests(ctx context.Context, interval time.Duration) ([]int64, error)
+	Pending(ctx context.Context, chatID int64, limit uint64) ([]*models.SubscriptionDelivery, error)
+	MarkDelivered(ctx context.Context, chatID int64, articleIDs ...int64) error
+	MarkDigestSent(ctx context.Context, chatID int64) error
+}
+
+// SetSubscriptions enables delivery of articles matching personal subscriptions
+// by direct message, either one by one or as a digest every digestInterval.
+func (n *Notifier) SetSubscriptions(subscriptions SubscriptionQueue, digestInterval time.Duration) {
+	n.subscriptions = subscriptions
+	n.digestInterval = digestInterval
+}
+
+func (n *Notifier) DeliverSubscriptions(ctx context.Context) error {
+	if n.subscriptions == nil {
+		return nil
+	}
+
+	if err := n.subscriptions.Enqueue(ctx, time.Now().Add(-n.lookupTimeWindow)); err != nil {
+		return fmt.Errorf("failed to enqueue subscription deliveries: %w", err)
+	}
+
+	if err := n.deliverInstant(ctx); err != nil {
+		return fmt.Errorf("failed to deliver instant subscriptions: %w", err)
+	}
+
+	if err := n.deliverDigests(ctx); err != nil {
+		return fmt.Errorf("failed to deliver digests: %w", err)
+	}
+
+	return nil
+}
+
+func (n *Notifier) deliverInstant(ctx context.Context) error {
+	deliveries, err := n.subscriptions.PendingInstant(ctx, instantDeliveryBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		msg := tgbotapi.NewMessage(delivery.ChatID, markup.NewBuilder().
+			Bold(delivery.Article.Title).
+			Text("\n\n"+delivery.Article.Link).
+			MarkdownV2())
+		msg.ParseMode = tgbotapi.ModeMarkdownV2
+
+		if !n.sendSubscription(ctx, msg) {
+			continue
+		}
+
+		if err := n.subscriptions.MarkDelivered(ctx, delivery.ChatID, delivery.Article.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (n *Notifier) deliverDigests(ctx context.Context) error {
+	chatIDs, err := n.subscriptions.DueDigests(ctx, n.digestInterval)
+	if err != nil {
+		return err
+	}
+
+	for _, chatID := range chatIDs {
+		deliveries, err := n.subscriptions.Pending(ctx, chatID, digestMaxArticles)
+		if err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			continue
+		}
+
+		var (
+			message    = markup.NewBuilder().Bold("Your digest").Text("\n")
+			articleIDs []int64
+		)
+		for _, delivery := range deliveries {
+			message.Text("\n• ").Link(delivery.Article.Title, delivery.Article.Link)
+			articleIDs = append(articleIDs, delivery.Article.ID)
+		}
+
+		msg := tgbotapi.NewMessage(chatID, message.MarkdownV2())
+		msg.ParseMode = tgbotapi.ModeMarkdownV2
+		msg.DisableWebPagePreview = true
+
+		if !n.sendSubscription(ctx, msg) {
+			continue
+		}
+
+		if err := n.subscriptions.MarkDelivered(ctx, chatID, articleIDs...); err != nil {
+			return err
+		}
+		if err := n.subscriptions.MarkDigestSent(ctx, chatID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendSubscription sends the message to the subscriber and reports whether
+// the articles in it are done with. They are also done with if the chat is
+// gone for good, e.g. the user blocked the bot; on other errors they stay
+// queued for the next tick, while the other subscribers are still served.
+func (n *Notifier) sendSubscription(ctx context.Context, msg tgbotapi.MessageConfig) bool {
+	_, err := n.sender.SendText(ctx, msg)
+	switch {
+	case err == nil:
+		return true
+	case botkit.IsChatUnavailableError(err):
+		slog.With("error", err.Error()).WarnContext(ctx, "subscriber chat is unavailable, dropping the delivery", "chat_id", msg.ChatID)
+		return true
+	default:
+		slog.With("error", err.Error()).WarnContext(ctx, "send subscription message, retrying on the next tick", "chat_id", msg.ChatID)
+		return false
+	}
+}
@@ -0,0 +1,226 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+	"github.com/to77e/news-fetching-bot/internal/botkit/markup"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	// DefaultTemplate renders the posts when neither the source nor the channel
+	// has a template.
+	DefaultTemplate = "*{{md .Title}}*{{if .Summary}}\n\n{{md .Summary}}{{end}}\n\n{{md .Link}}"
+)
+
+var ErrInvalidTemplate = errors.New("invalid template")
+
+var defaultTemplate = template.Must(ParseTemplate(tgbotapi.ModeMarkdownV2, DefaultTemplate))
+
+var templateFuncs = template.FuncMap{
+	"md":    markup.EscapeForMarkdown,
+	"mdurl": markup.EscapeForMarkdownLink,
+	"html":  html.EscapeString,
+	"join":  strings.Join,
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"truncate": func(size int, text string) string {
+		runes := []rune(text)
+		if len(runes) <= size {
+			return text
+		}
+		return string(runes[:max(size-1, 0)]) + "…"
+	},
+}
+
+// PostData is the data available to post templates.
+type PostData struct {
+	ID        int64
+	Title     string
+	Summary   string
+	Link      string
+	SourceID  int64
+	Source    string
+	Tags      []string
+	Author    string
+	ImageURL  string
+	Published time.Time
+}
+
+// SamplePost is rendered to validate and preview templates.
+var SamplePost = PostData{
+	ID:        42,
+	Title:     "Go 1.22 is released!",
+	Summary:   "The new release brings range over integers, an enhanced routing in net/http and fixes the loop variable capture.",
+	Link:      "https://go.dev/blog/go1.22",
+	SourceID:  1,
+	Source:    "The Go Blog",
+	Tags:      []string{"golang", "release"},
+	Author:    "Eli Bendersky",
+	Published: time.Date(2024, time.February, 6, 17, 0, 0, 0, time.UTC),
+}
+
+// TemplateProvider provides the post templates. Template returns nil without
+// an error when the scope and target have no template.
+type TemplateProvider interface {
+	Template(ctx context.Context, scope string, targetID int64) (*models.PostTemplate, error)
+}
+
+// SetTemplates makes the notifier render posts with the templates of the
+// sources and the channel.
+func (n *Notifier) SetTemplates(templates TemplateProvider, sources SourceProvider) {
+	n.templates = templates
+	n.sources = sources
+}
+
+// ParseTemplate parses the template and renders the sample post to catch
+// errors which only show up on execution.
+func ParseTemplate(parseMode, body string) (*template.Template, error) {
+	if parseMode != tgbotapi.ModeMarkdownV2 && parseMode != tgbotapi.ModeHTML {
+		return nil, fmt.Errorf("parse mode %q: %w", parseMode, ErrInvalidTemplate)
+	}
+
+	tmpl, err := template.New("post").Funcs(templateFuncs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+
+	text, err := RenderTemplate(tmpl, SamplePost)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("%w: the rendered post is empty", ErrInvalidTemplate)
+	}
+	if botkit.RenderedLen(text, parseMode) > botkit.MessageMaxSize {
+		return nil, fmt.Errorf("%w: the rendered post is longer than %d characters", ErrInvalidTemplate, botkit.MessageMaxSize)
+	}
+
+	return tmpl, nil
+}
+
+func RenderTemplate(tmpl *template.Template, data PostData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// postData returns the data of the post of the article with the summary.
+func (n *Notifier) postData(ctx context.Context, article *models.Article, summary string) (PostData, error) {
+	data := PostData{
+		ID:        article.ID,
+		Title:     article.Title,
+		Summary:   summary,
+		Link:      article.Link,
+		SourceID:  article.SourceID,
+		Tags:      article.Tags,
+		Author:    article.Author,
+		ImageURL:  article.ImageURL,
+		Published: article.PublishedDate,
+	}
+
+	if n.sources != nil {
+		source, err := n.sources.SourceByID(ctx, article.SourceID)
+		if err != nil {
+			return PostData{}, fmt.Errorf("failed to get source: %w", err)
+		}
+		data.Source = source.Name
+	}
+
+	return data, nil
+}
+
+// renderPost renders the post with the template of its source, of the channel
+// or the default one, and returns the text with its parse mode.
+func (n *Notifier) renderPost(ctx context.Context, data PostData) (string, string, error) {
+	var (
+		parseMode = tgbotapi.ModeMarkdownV2
+		tmpl      = defaultTemplate
+		err       error
+	)
+	if n.templates != nil {
+		var postTemplate *models.PostTemplate
+		if postTemplate, err = n.postTemplate(ctx, data.SourceID); err != nil {
+			return "", "", err
+		}
+		if postTemplate != nil {
+			parseMode = postTemplate.ParseMode
+			tmpl, err = n.parseTemplate(postTemplate)
+		}
+	}
+
+	if err == nil {
+		var text string
+		if text, err = RenderTemplate(tmpl, data); err == nil {
+			return text, parseMode, nil
+		}
+	}
+
+	slog.With("error", err.Error()).WarnContext(ctx, "render post template, falling back to the default one", "article", data.ID)
+
+	text, err := RenderTemplate(defaultTemplate, data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render default template: %w", err)
+	}
+	return text, tgbotapi.ModeMarkdownV2, nil
+}
+
+type parsedTemplate struct {
+	updated time.Time
+	tmpl    *template.Template
+	err     error
+}
+
+// parseTemplate returns the parsed template, which is parsed and validated
+// once after every change.
+func (n *Notifier) parseTemplate(postTemplate *models.PostTemplate) (*template.Template, error) {
+	n.templatesMu.Lock()
+	defer n.templatesMu.Unlock()
+
+	if parsed, ok := n.parsedTemplates[postTemplate.ID]; ok && parsed.updated.Equal(postTemplate.UpdatedDate) {
+		return parsed.tmpl, parsed.err
+	}
+
+	tmpl, err := ParseTemplate(postTemplate.ParseMode, postTemplate.Body)
+	if n.parsedTemplates == nil {
+		n.parsedTemplates = make(map[int64]parsedTemplate)
+	}
+	n.parsedTemplates[postTemplate.ID] = parsedTemplate{updated: postTemplate.UpdatedDate, tmpl: tmpl, err: err}
+
+	return tmpl, err
+}
+
+// postTemplate returns the template of the source or of the channel, or nil
+// if there is none.
+func (n *Notifier) postTemplate(ctx context.Context, sourceID int64) (*models.PostTemplate, error) {
+	for _, v := range []struct {
+		scope    string
+		targetID int64
+	}{
+		{models.TemplateScopeSource, sourceID},
+		{models.TemplateScopeChannel, n.channelID},
+	} {
+		postTemplate, err := n.templates.Template(ctx, v.scope, v.targetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get template: %w", err)
+		}
+		if postTemplate != nil {
+			return postTemplate, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,115 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/notifier"
+)
+
+const (
+	discordTitleMaxSize       = 256
+	discordDescriptionMaxSize = 4096
+	discordFooterMaxSize      = 2048
+	discordColor              = 0x5865F2
+)
+
+// DiscordPublisher posts the articles to a Discord channel through a webhook
+// as embeds.
+type DiscordPublisher struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func NewDiscordPublisher(webhookURL string) *DiscordPublisher {
+	return &DiscordPublisher{webhookURL: webhookURL, client: http.DefaultClient}
+}
+
+func (p *DiscordPublisher) Destination() string {
+	return models.DestinationDiscord
+}
+
+func (p *DiscordPublisher) Publish(ctx context.Context, post notifier.PostData) (models.Delivery, error) {
+	// wait makes Discord return the created message
+	webhookURL, err := url.Parse(p.webhookURL)
+	if err != nil {
+		return models.Delivery{}, fmt.Errorf("parse webhook url: %w", err)
+	}
+	query := webhookURL.Query()
+	query.Set("wait", "true")
+	webhookURL.RawQuery = query.Encode()
+
+	var message struct {
+		ID        string `json:"id"`
+		ChannelID string `json:"channel_id"`
+	}
+	if err := postJSON(ctx, p.client, webhookURL.String(), discordMessage(post), &message); err != nil {
+		return models.Delivery{}, err
+	}
+
+	// the IDs are snowflakes which fit into int64
+	messageID, _ := strconv.ParseInt(message.ID, 10, 64)
+	channelID, _ := strconv.ParseInt(message.ChannelID, 10, 64)
+
+	return models.Delivery{ChatID: channelID, MessageID: messageID}, nil
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	URL         string         `json:"url"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+	Author      *discordAuthor `json:"author,omitempty"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+	Image       *discordImage  `json:"image,omitempty"`
+}
+
+type discordAuthor struct {
+	Name string `json:"name"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+type discordImage struct {
+	URL string `json:"url"`
+}
+
+func discordMessage(post notifier.PostData) map[string]any {
+	embed := discordEmbed{
+		Title:       truncate(post.Title, discordTitleMaxSize),
+		URL:         post.Link,
+		Description: truncate(escapeMarkdown(post.Summary), discordDescriptionMaxSize),
+		Color:       discordColor,
+	}
+	if !post.Published.IsZero() {
+		embed.Timestamp = post.Published.UTC().Format(time.RFC3339)
+	}
+	if post.Author != "" {
+		embed.Author = &discordAuthor{Name: post.Author}
+	}
+	if post.ImageURL != "" {
+		embed.Image = &discordImage{URL: post.ImageURL}
+	}
+
+	var footer []string
+	if post.Source != "" {
+		footer = append(footer, post.Source)
+	}
+	for _, tag := range post.Tags {
+		footer = append(footer, "#"+tag)
+	}
+	if len(footer) > 0 {
+		embed.Footer = &discordFooter{Text: truncate(strings.Join(footer, " · "), discordFooterMaxSize)}
+	}
+
+	return map[string]any{"embeds": []discordEmbed{embed}}
+}
@@ -0,0 +1,80 @@
+package publisher
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/notifier"
+)
+
+const (
+	mattermostTextMaxSize = 16000
+	mattermostColor       = "#1E325C"
+)
+
+// MattermostPublisher posts the articles to a Mattermost channel through an
+// incoming webhook as message attachments.
+type MattermostPublisher struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func NewMattermostPublisher(webhookURL string) *MattermostPublisher {
+	return &MattermostPublisher{webhookURL: webhookURL, client: http.DefaultClient}
+}
+
+func (p *MattermostPublisher) Destination() string {
+	return models.DestinationMattermost
+}
+
+// Publish posts the article. Incoming webhooks don't return the post, so the
+// delivery is empty.
+func (p *MattermostPublisher) Publish(ctx context.Context, post notifier.PostData) (models.Delivery, error) {
+	if err := postJSON(ctx, p.client, p.webhookURL, mattermostMessage(post), nil); err != nil {
+		return models.Delivery{}, err
+	}
+	return models.Delivery{}, nil
+}
+
+type mattermostAttachment struct {
+	Fallback   string `json:"fallback"`
+	Color      string `json:"color"`
+	AuthorName string `json:"author_name,omitempty"`
+	Title      string `json:"title"`
+	TitleLink  string `json:"title_link"`
+	Text       string `json:"text,omitempty"`
+	ImageURL   string `json:"image_url,omitempty"`
+	Footer     string `json:"footer,omitempty"`
+}
+
+func mattermostMessage(post notifier.PostData) map[string]any {
+	footer := []string{post.Published.Format(dateFmt)}
+	if post.Source != "" {
+		footer = append([]string{post.Source}, footer...)
+	}
+
+	text := escapeMarkdown(post.Summary)
+	if len(post.Tags) > 0 {
+		// hashtags are searchable in Mattermost
+		tags := make([]string, 0, len(post.Tags))
+		for _, tag := range post.Tags {
+			tags = append(tags, "#"+strings.Join(strings.Fields(tag), "_"))
+		}
+		text += "\n\n" + strings.Join(tags, " ")
+	}
+
+	return map[string]any{
+		"attachments": []mattermostAttachment{{
+			Fallback:   post.Title + " " + post.Link,
+			Color:      mattermostColor,
+			AuthorName: post.Author,
+			Title:      post.Title,
+			TitleLink:  post.Link,
+			Text:       truncate(text, mattermostTextMaxSize),
+			ImageURL:   post.ImageURL,
+			Footer:     strings.Join(footer, " · "),
+		}},
+	}
+}
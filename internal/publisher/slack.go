@@ -0,0 +1,90 @@
+package publisher
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/notifier"
+)
+
+const (
+	slackTextMaxSize    = 3000
+	slackAltTextMaxSize = 2000
+)
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// SlackPublisher posts the articles to a Slack channel through an incoming
+// webhook as Block Kit messages.
+type SlackPublisher struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func NewSlackPublisher(webhookURL string) *SlackPublisher {
+	return &SlackPublisher{webhookURL: webhookURL, client: http.DefaultClient}
+}
+
+func (p *SlackPublisher) Destination() string {
+	return models.DestinationSlack
+}
+
+// Publish posts the article. Incoming webhooks don't return the message, so
+// the delivery is empty.
+func (p *SlackPublisher) Publish(ctx context.Context, post notifier.PostData) (models.Delivery, error) {
+	if err := postJSON(ctx, p.client, p.webhookURL, slackMessage(post), nil); err != nil {
+		return models.Delivery{}, err
+	}
+	return models.Delivery{}, nil
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type      string      `json:"type"`
+	Text      *slackText  `json:"text,omitempty"`
+	Accessory *slackImage `json:"accessory,omitempty"`
+	Elements  []slackText `json:"elements,omitempty"`
+}
+
+type slackImage struct {
+	Type     string `json:"type"`
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+func slackMessage(post notifier.PostData) map[string]any {
+	title := "*<" + post.Link + "|" + slackEscaper.Replace(strings.ReplaceAll(post.Title, "|", "¦")) + ">*"
+	section := slackBlock{
+		Type: "section",
+		Text: &slackText{Type: "mrkdwn", Text: truncate(title+"\n\n"+slackEscaper.Replace(post.Summary), slackTextMaxSize)},
+	}
+	if post.ImageURL != "" {
+		section.Accessory = &slackImage{Type: "image", ImageURL: post.ImageURL, AltText: truncate(post.Title, slackAltTextMaxSize)}
+	}
+
+	details := []string{post.Published.Format(dateFmt)}
+	if post.Source != "" {
+		details = append([]string{slackEscaper.Replace(post.Source)}, details...)
+	}
+	if post.Author != "" {
+		details = append(details, slackEscaper.Replace(post.Author))
+	}
+	for _, tag := range post.Tags {
+		details = append(details, "#"+slackEscaper.Replace(tag))
+	}
+
+	return map[string]any{
+		// the text is shown in notifications
+		"text": post.Title,
+		"blocks": []slackBlock{
+			section,
+			{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: strings.Join(details, " · ")}}},
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/to77e/news-fetching-bot/internal/retry"
+)
+
+const (
+	requestTimeout = 10 * time.Second
+	maxAttempts    = 3
+	retryDelay     = time.Second
+	maxRetryAfter  = time.Minute
+
+	dateFmt = "2006-01-02 15:04"
+)
+
+// postJSON posts the payload to the incoming webhook and decodes the response
+// into the result unless it is nil. Rate limited requests are retried after
+// the delay the service asks for, other failures are not, since the message
+// may have been posted.
+func postJSON(ctx context.Context, client *http.Client, url string, payload, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	policy := retry.Policy{MaxAttempts: maxAttempts, Delay: retryDelay, MaxDelay: maxRetryAfter}
+	_, err = retry.Do(ctx, policy, func(ctx context.Context) error {
+		return doPost(ctx, client, url, body, result)
+	})
+	return err
+}
+
+// doPost makes a single request. A rate limited request is marked as
+// retryable after the delay in the Retry-After header.
+func doPost(ctx context.Context, client *http.Client, url string, body []byte, result any) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return retry.After(retry.StatusError(resp), retry.RetryAfter(resp.Header.Get("Retry-After"), maxRetryAfter))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return retry.StatusError(resp)
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
+
+// truncate cuts the text to at most size characters.
+func truncate(text string, size int) string {
+	runes := []rune(text)
+	if len(runes) <= size {
+		return text
+	}
+	return string(runes[:max(size-1, 0)]) + "…"
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`, "[", `\[`, "]", `\]`,
+)
+
+// escapeMarkdown escapes the text for the markdown of Discord and Mattermost.
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
@@ -3,22 +3,57 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/to77e/news-fetching-bot/internal/models"
 )
 
+var (
+	ErrorArticleNotFound = errors.New("article not found")
+)
+
+const (
+	articleColumns = `id, source_id, title, link, summary, tags, author, image_url, published_at, created_at, posted_at,
+		post_summary, moderation_status, moderated_by, moderated_at, score, score_details, scored_at,
+		content_hash, content_updated_at, edited_at, retracted_at`
+)
+
 type dbArticle struct {
 	ID            int64        `db:"id"`
 	SourceID      int64        `db:"source_id"`
 	Title         string       `db:"title"`
 	Link          string       `db:"link"`
 	Summary       string       `db:"summary"`
+	Tags          []string     `db:"tags"`
+	Author        string       `db:"author"`
+	ImageURL      string       `db:"image_url"`
 	PublishedDate time.Time    `db:"published_at"`
 	PostedDate    sql.NullTime `db:"posted_at"`
 	CreatedDate   time.Time    `db:"created_at"`
+
+	PostSummary      sql.NullString `db:"post_summary"`
+	ModerationStatus string         `db:"moderation_status"`
+	ModeratedBy      int64          `db:"moderated_by"`
+	ModeratedDate    sql.NullTime   `db:"moderated_at"`
+
+	Score        float64         `db:"score"`
+	ScoreFactors []dbScoreFactor `db:"score_details"`
+	ScoredDate   sql.NullTime    `db:"scored_at"`
+
+	ContentHash        string       `db:"content_hash"`
+	ContentUpdatedDate sql.NullTime `db:"content_updated_at"`
+	EditedDate         sql.NullTime `db:"edited_at"`
+	RetractedDate      sql.NullTime `db:"retracted_at"`
+}
+
+type dbScoreFactor struct {
+	Name   string  `json:"name"`
+	Value  float64 `json:"value"`
+	Weight float64 `json:"weight"`
 }
 
 type ArticleRepository struct {
@@ -29,29 +64,73 @@ func NewArticleRepository(db *pgxpool.Pool) *ArticleRepository {
 	return &ArticleRepository{db: db}
 }
 
-func (a *ArticleRepository) Store(ctx context.Context, article models.Article) error {
+// Store stores the article. An article with the same link is updated if its
+// content hash differs, unless it is retracted; the summary generated for the
+// post is dropped when the summary of the article changes. An article without
+// a hash yet takes the new one without counting as changed. It returns the ID
+// of the inserted article, or 0 if the article was already stored.
+func (a *ArticleRepository) Store(ctx context.Context, article models.Article) (int64, error) {
 	const (
 		query = `
-			INSERT INTO articles (source_id, title, link, summary, published_at)
-			VALUES ($1, $2, $3, $4, $5)
-			ON CONFLICT DO NOTHING;`
+			INSERT INTO articles (source_id, title, link, summary, tags, author, image_url, published_at, content_hash)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (link) DO UPDATE
+			SET title = EXCLUDED.title,
+			    summary = EXCLUDED.summary,
+			    tags = EXCLUDED.tags,
+			    content_hash = EXCLUDED.content_hash,
+			    content_updated_at = CASE WHEN articles.content_hash = '' THEN articles.content_updated_at ELSE NOW() END,
+			    post_summary = CASE
+			        WHEN articles.content_hash = '' OR articles.summary = EXCLUDED.summary THEN articles.post_summary
+			    END
+			WHERE articles.content_hash <> EXCLUDED.content_hash AND articles.retracted_at IS NULL
+			RETURNING id, xmax = 0;`
 	)
 
-	_, err := a.db.Exec(ctx, query, article.SourceID, article.Title, article.Link, article.Summary, article.PublishedDate)
+	tags := article.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	var (
+		id       int64
+		inserted bool
+	)
+	err := a.db.QueryRow(ctx, query,
+		article.SourceID,
+		article.Title,
+		article.Link,
+		article.Summary,
+		tags,
+		article.Author,
+		article.ImageURL,
+		article.PublishedDate,
+		article.ContentHash,
+	).Scan(&id, &inserted)
 	if err != nil {
-		return fmt.Errorf("insert article: %w", err)
+		// no row is returned if the stored article is unchanged
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("insert article: %w", err)
+	}
+	if !inserted {
+		return 0, nil
 	}
 
-	return nil
+	return id, nil
 }
 
 func (a *ArticleRepository) AllNotPosted(ctx context.Context, since time.Time, limit uint64) ([]*models.Article, error) {
 	const (
 		query = `
-			SELECT id, source_id, title, link, summary, published_at, created_at, posted_at
-			FROM articles 
-			WHERE posted_at IS NULL AND published_at >= $1::TIMESTAMP
-			ORDER BY published_at DESC
+			SELECT ` + articleColumns + `
+			FROM articles
+			WHERE posted_at IS NULL AND retracted_at IS NULL AND moderation_status = 'none'
+			  AND published_at >= $1::TIMESTAMP
+			ORDER BY published_at + (COALESCE(
+				(SELECT q.score FROM source_quality q WHERE q.source_id = articles.source_id), 0.5
+			) - 0.5) * INTERVAL '12 hours' DESC
 			LIMIT $2;`
 	)
 
@@ -59,36 +138,257 @@ func (a *ArticleRepository) AllNotPosted(ctx context.Context, since time.Time, l
 	if err != nil {
 		return nil, fmt.Errorf("select articles: %w", err)
 	}
-	defer rows.Close()
 
-	var articles []*models.Article
-	for rows.Next() {
-		var article dbArticle
-		if err := rows.Scan(
-			&article.ID,
-			&article.SourceID,
-			&article.Title,
-			&article.Link,
-			&article.Summary,
-			&article.PublishedDate,
-			&article.CreatedDate,
-			&article.PostedDate); err != nil {
-			return nil, err
+	return scanArticles(rows)
+}
+
+// AllNotDelivered returns the articles posted since the time which are not
+// posted to the destination yet, the best scored first.
+func (a *ArticleRepository) AllNotDelivered(
+	ctx context.Context,
+	destination string,
+	since time.Time,
+	limit uint64,
+) ([]*models.Article, error) {
+	const (
+		query = `
+			SELECT ` + articleColumns + `
+			FROM articles
+			WHERE posted_at >= $2::TIMESTAMP AND retracted_at IS NULL
+			  AND NOT EXISTS (
+			      SELECT 1 FROM deliveries d
+			      WHERE d.article_id = articles.id AND d.destination = $1)
+			ORDER BY score DESC, posted_at
+			LIMIT $3;`
+	)
+
+	rows, err := a.db.Query(ctx, query, destination, since.UTC().Format(time.RFC3339), limit)
+	if err != nil {
+		return nil, fmt.Errorf("select articles: %w", err)
+	}
+
+	return scanArticles(rows)
+}
+
+// AllApproved returns the articles approved by moderators and not posted yet
+// in the order of approval.
+func (a *ArticleRepository) AllApproved(ctx context.Context, limit uint64) ([]*models.Article, error) {
+	const (
+		query = `
+			SELECT ` + articleColumns + `
+			FROM articles
+			WHERE posted_at IS NULL AND retracted_at IS NULL AND moderation_status = 'approved'
+			ORDER BY moderated_at
+			LIMIT $1;`
+	)
+
+	rows, err := a.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select approved articles: %w", err)
+	}
+
+	return scanArticles(rows)
+}
+
+// AllPending returns the articles waiting for moderation in the order they
+// were sent to the moderators.
+func (a *ArticleRepository) AllPending(ctx context.Context, limit uint64) ([]*models.Article, error) {
+	const (
+		query = `
+			SELECT ` + articleColumns + `
+			FROM articles
+			WHERE posted_at IS NULL AND retracted_at IS NULL AND moderation_status = 'pending'
+			ORDER BY id
+			LIMIT $1;`
+	)
+
+	rows, err := a.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select pending articles: %w", err)
+	}
+
+	return scanArticles(rows)
+}
+
+// AllChanged returns the posted articles whose content changed since they
+// were posted or last edited. Articles posted, edited or failed to be edited
+// after editedBefore wait, and the ones failed maxFailures times are skipped.
+func (a *ArticleRepository) AllChanged(
+	ctx context.Context,
+	editedBefore time.Time,
+	maxFailures int,
+	limit uint64,
+) ([]*models.Article, error) {
+	const (
+		query = `
+			SELECT ` + articleColumns + `
+			FROM articles
+			WHERE posted_at IS NOT NULL AND retracted_at IS NULL
+			  AND content_updated_at > COALESCE(edited_at, posted_at)
+			  AND COALESCE(edited_at, posted_at) < $1::TIMESTAMP
+			  AND (edit_failed_at IS NULL OR edit_failed_at < $1::TIMESTAMP)
+			  AND edit_failures < $2
+			ORDER BY content_updated_at
+			LIMIT $3;`
+	)
+
+	rows, err := a.db.Query(ctx, query, editedBefore.UTC().Format(time.RFC3339), maxFailures, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select changed articles: %w", err)
+	}
+
+	return scanArticles(rows)
+}
+
+// TopPosted returns the best scored articles posted since the time.
+func (a *ArticleRepository) TopPosted(ctx context.Context, since time.Time, limit uint64) ([]*models.Article, error) {
+	const (
+		query = `
+			SELECT ` + articleColumns + `
+			FROM articles
+			WHERE posted_at >= $1::TIMESTAMP AND retracted_at IS NULL
+			ORDER BY score DESC, posted_at DESC
+			LIMIT $2;`
+	)
+
+	rows, err := a.db.Query(ctx, query, since.UTC().Format(time.RFC3339), limit)
+	if err != nil {
+		return nil, fmt.Errorf("select posted articles: %w", err)
+	}
+
+	return scanArticles(rows)
+}
+
+// QueueStats counts the articles waiting to be posted. Articles selected
+// automatically are only counted if they are published since the time.
+func (a *ArticleRepository) QueueStats(ctx context.Context, since time.Time) (models.QueueStats, error) {
+	const (
+		query = `
+			SELECT
+				COUNT(*) FILTER (WHERE moderation_status = 'none' AND published_at >= $1::TIMESTAMP),
+				COUNT(*) FILTER (WHERE moderation_status = 'pending'),
+				COUNT(*) FILTER (WHERE moderation_status = 'approved')
+			FROM articles
+			WHERE posted_at IS NULL AND retracted_at IS NULL;`
+	)
+
+	var stats models.QueueStats
+	if err := a.db.QueryRow(ctx, query, since.UTC().Format(time.RFC3339)).Scan(
+		&stats.NotPosted,
+		&stats.Pending,
+		&stats.Approved,
+	); err != nil {
+		return models.QueueStats{}, fmt.Errorf("count queued articles: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (a *ArticleRepository) ArticleByID(ctx context.Context, id int64) (*models.Article, error) {
+	const (
+		query = `
+			SELECT ` + articleColumns + `
+			FROM articles
+			WHERE id = $1;`
+	)
+
+	article, err := scanArticle(a.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrorArticleNotFound
 		}
+		return nil, fmt.Errorf("select article by id %d: %w", id, err)
+	}
+
+	return article, nil
+}
+
+// Latest returns a page of the most recently published articles, optionally
+// of one source and published in the period, and the total number of them.
+func (a *ArticleRepository) Latest(ctx context.Context, q models.ArticleQuery) ([]*models.Article, int, error) {
+	const (
+		filter = `
+			FROM articles
+			WHERE ($1::BIGINT = 0 OR source_id = $1::BIGINT)
+			  AND ($2::TIMESTAMP IS NULL OR published_at >= $2::TIMESTAMP)
+			  AND ($3::TIMESTAMP IS NULL OR published_at < $3::TIMESTAMP)`
+		query = `
+			SELECT ` + articleColumns + filter + `
+			ORDER BY published_at DESC, id DESC
+			LIMIT $4 OFFSET $5;`
+		countQuery = `SELECT COUNT(*)` + filter + `;`
+	)
+
+	return a.page(ctx, query, countQuery, q, q.SourceID, nullTime(q.From), nullTime(q.To))
+}
+
+// Search returns the articles matching the query ranked by relevance and the
+// total number of matches.
+func (a *ArticleRepository) Search(ctx context.Context, q models.ArticleQuery) ([]*models.Article, int, error) {
+	const (
+		filter = `
+			FROM articles,
+			     (SELECT websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1) AS query) q
+			WHERE search_vector @@ q.query
+			  AND ($2::BIGINT = 0 OR source_id = $2::BIGINT)
+			  AND ($3::TIMESTAMP IS NULL OR published_at >= $3::TIMESTAMP)
+			  AND ($4::TIMESTAMP IS NULL OR published_at < $4::TIMESTAMP)`
+		query = `
+			SELECT ` + articleColumns + filter + `
+			ORDER BY ts_rank(search_vector, q.query) DESC, published_at DESC
+			LIMIT $5 OFFSET $6;`
+		countQuery = `SELECT COUNT(*)` + filter + `;`
+	)
 
-		articles = append(articles, &models.Article{
-			ID:            article.ID,
-			SourceID:      article.SourceID,
-			Title:         article.Title,
-			Link:          article.Link,
-			Summary:       article.Summary,
-			PublishedDate: article.PublishedDate,
-			PostedDate:    article.PostedDate.Time,
-			CreatedDate:   article.CreatedDate,
-		})
+	return a.page(ctx, query, countQuery, q, q.Text, q.SourceID, nullTime(q.From), nullTime(q.To))
+}
+
+// Posted returns a page of the posted articles, optionally with the tag or
+// delivered to the chat, most recently posted first, and the total number of
+// them.
+func (a *ArticleRepository) Posted(ctx context.Context, q models.ArticleQuery) ([]*models.Article, int, error) {
+	const (
+		filter = `
+			FROM articles
+			WHERE posted_at IS NOT NULL AND retracted_at IS NULL
+			  AND ($1 = '' OR EXISTS (SELECT 1 FROM unnest(tags) t WHERE lower(t) = lower($1)))
+			  AND ($2::BIGINT = 0 OR EXISTS (
+			      SELECT 1 FROM deliveries d
+			      WHERE d.article_id = articles.id AND d.chat_id = $2::BIGINT AND d.deleted_at IS NULL))`
+		query = `
+			SELECT ` + articleColumns + filter + `
+			ORDER BY posted_at DESC, id DESC
+			LIMIT $3 OFFSET $4;`
+		countQuery = `SELECT COUNT(*)` + filter + `;`
+	)
+
+	return a.page(ctx, query, countQuery, q, q.Tag, q.ChatID)
+}
+
+// page runs a query selecting articles with the filter arguments followed by
+// limit and offset, and a query counting all the articles matching the filter.
+func (a *ArticleRepository) page(
+	ctx context.Context,
+	query, countQuery string,
+	q models.ArticleQuery,
+	args ...any,
+) ([]*models.Article, int, error) {
+	var total int
+	if err := a.db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count articles: %w", err)
 	}
 
-	return articles, nil
+	rows, err := a.db.Query(ctx, query, append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("select articles: %w", err)
+	}
+
+	articles, err := scanArticles(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return articles, total, nil
 }
 
 func (a *ArticleRepository) MarkPosted(ctx context.Context, id int64) error {
@@ -103,3 +403,208 @@ func (a *ArticleRepository) MarkPosted(ctx context.Context, id int64) error {
 
 	return nil
 }
+
+// MarkEdited records that the posted messages of the article are updated to
+// its current content.
+func (a *ArticleRepository) MarkEdited(ctx context.Context, id int64) error {
+	const (
+		query = `UPDATE articles SET edited_at = NOW(), edit_failures = 0, edit_failed_at = NULL WHERE id = $1;`
+	)
+
+	_, err := a.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("update article: %w", err)
+	}
+
+	return nil
+}
+
+// MarkEditFailed records a failed attempt to edit the posted messages of the
+// article.
+func (a *ArticleRepository) MarkEditFailed(ctx context.Context, id int64) error {
+	const (
+		query = `UPDATE articles SET edit_failures = edit_failures + 1, edit_failed_at = NOW() WHERE id = $1;`
+	)
+
+	_, err := a.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("update article: %w", err)
+	}
+
+	return nil
+}
+
+// MarkRetracted excludes the article from posting and from updates by the
+// fetcher.
+func (a *ArticleRepository) MarkRetracted(ctx context.Context, id int64) error {
+	const (
+		query = `UPDATE articles SET retracted_at = NOW() WHERE id = $1;`
+	)
+
+	tag, err := a.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("update article: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrorArticleNotFound
+	}
+
+	return nil
+}
+
+func (a *ArticleRepository) SetPostSummary(ctx context.Context, id int64, summary string) error {
+	const (
+		query = `UPDATE articles SET post_summary = $2 WHERE id = $1;`
+	)
+
+	_, err := a.db.Exec(ctx, query, id, summary)
+	if err != nil {
+		return fmt.Errorf("update article summary: %w", err)
+	}
+
+	return nil
+}
+
+func (a *ArticleRepository) MarkPending(ctx context.Context, id int64) error {
+	const (
+		query = `UPDATE articles SET moderation_status = 'pending' WHERE id = $1;`
+	)
+
+	_, err := a.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("update article: %w", err)
+	}
+
+	return nil
+}
+
+// SetScore stores the score of the article with the factors explaining it.
+func (a *ArticleRepository) SetScore(ctx context.Context, id int64, score float64, factors []models.ScoreFactor) error {
+	const (
+		query = `UPDATE articles SET score = $2, score_details = $3, scored_at = NOW() WHERE id = $1;`
+	)
+
+	details := make([]dbScoreFactor, 0, len(factors))
+	for _, v := range factors {
+		details = append(details, dbScoreFactor{Name: v.Name, Value: v.Value, Weight: v.Weight})
+	}
+
+	_, err := a.db.Exec(ctx, query, id, score, details)
+	if err != nil {
+		return fmt.Errorf("update article score: %w", err)
+	}
+
+	return nil
+}
+
+// Moderate records the decision of a moderator on the article.
+func (a *ArticleRepository) Moderate(ctx context.Context, id int64, status string, moderatorID int64) error {
+	const (
+		query = `
+			UPDATE articles
+			SET moderation_status = $2, moderated_by = $3, moderated_at = NOW()
+			WHERE id = $1;`
+	)
+
+	tag, err := a.db.Exec(ctx, query, id, status, moderatorID)
+	if err != nil {
+		return fmt.Errorf("update article moderation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrorArticleNotFound
+	}
+
+	return nil
+}
+
+func nullTime(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	value := t.UTC().Format(time.RFC3339)
+	return &value
+}
+
+func scanArticles(rows pgx.Rows) ([]*models.Article, error) {
+	defer rows.Close()
+
+	var articles []*models.Article
+	for rows.Next() {
+		article, err := scanArticle(rows)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, article)
+	}
+
+	return articles, rows.Err()
+}
+
+func scanArticle(row pgx.Row) (*models.Article, error) {
+	var article dbArticle
+	if err := row.Scan(article.scanDest()...); err != nil {
+		return nil, err
+	}
+
+	return article.model(), nil
+}
+
+// scanDest returns the scan destinations of articleColumns.
+func (a *dbArticle) scanDest() []any {
+	return []any{
+		&a.ID,
+		&a.SourceID,
+		&a.Title,
+		&a.Link,
+		&a.Summary,
+		&a.Tags,
+		&a.Author,
+		&a.ImageURL,
+		&a.PublishedDate,
+		&a.CreatedDate,
+		&a.PostedDate,
+		&a.PostSummary,
+		&a.ModerationStatus,
+		&a.ModeratedBy,
+		&a.ModeratedDate,
+		&a.Score,
+		&a.ScoreFactors,
+		&a.ScoredDate,
+		&a.ContentHash,
+		&a.ContentUpdatedDate,
+		&a.EditedDate,
+		&a.RetractedDate,
+	}
+}
+
+func (a dbArticle) model() *models.Article {
+	factors := make([]models.ScoreFactor, 0, len(a.ScoreFactors))
+	for _, v := range a.ScoreFactors {
+		factors = append(factors, models.ScoreFactor{Name: v.Name, Value: v.Value, Weight: v.Weight})
+	}
+
+	return &models.Article{
+		ID:                 a.ID,
+		SourceID:           a.SourceID,
+		Title:              a.Title,
+		Link:               a.Link,
+		Summary:            a.Summary,
+		Tags:               a.Tags,
+		Author:             a.Author,
+		ImageURL:           a.ImageURL,
+		PublishedDate:      a.PublishedDate,
+		PostedDate:         a.PostedDate.Time,
+		CreatedDate:        a.CreatedDate,
+		PostSummary:        a.PostSummary.String,
+		ModerationStatus:   a.ModerationStatus,
+		ModeratedBy:        a.ModeratedBy,
+		ModeratedDate:      a.ModeratedDate.Time,
+		Score:              a.Score,
+		ScoreFactors:       factors,
+		ScoredDate:         a.ScoredDate.Time,
+		ContentHash:        a.ContentHash,
+		ContentUpdatedDate: a.ContentUpdatedDate.Time,
+		EditedDate:         a.EditedDate.Time,
+		RetractedDate:      a.RetractedDate.Time,
+	}
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/to77e/news-fetching-bot/internal/botkit"
+)
+
+type dbConversation struct {
+	ChatID    int64     `db:"chat_id"`
+	Name      string    `db:"name"`
+	Step      int       `db:"step"`
+	Answers   []byte    `db:"answers"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type ConversationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewConversationRepository(db *pgxpool.Pool) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+func (c *ConversationRepository) Get(ctx context.Context, chatID int64) (*botkit.ConversationState, error) {
+	const (
+		query = `
+			SELECT chat_id, name, step, answers, expires_at
+			FROM conversations
+			WHERE chat_id = $1 AND expires_at > NOW();`
+	)
+
+	var conversation dbConversation
+	err := c.db.QueryRow(ctx, query, chatID).Scan(
+		&conversation.ChatID,
+		&conversation.Name,
+		&conversation.Step,
+		&conversation.Answers,
+		&conversation.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("select conversation by chat id %d: %w", chatID, err)
+	}
+
+	answers := make(map[string]string)
+	if err := json.Unmarshal(conversation.Answers, &answers); err != nil {
+		return nil, fmt.Errorf("unmarshal answers: %w", err)
+	}
+
+	return &botkit.ConversationState{
+		ChatID:    conversation.ChatID,
+		Name:      conversation.Name,
+		Step:      conversation.Step,
+		Answers:   answers,
+		ExpiresAt: conversation.ExpiresAt,
+	}, nil
+}
+
+func (c *ConversationRepository) Save(ctx context.Context, state botkit.ConversationState) error {
+	const (
+		query = `
+			INSERT INTO conversations (chat_id, name, step, answers, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (chat_id) DO UPDATE
+			SET name = EXCLUDED.name,
+			    step = EXCLUDED.step,
+			    answers = EXCLUDED.answers,
+			    expires_at = EXCLUDED.expires_at;`
+	)
+
+	answers, err := json.Marshal(state.Answers)
+	if err != nil {
+		return fmt.Errorf("marshal answers: %w", err)
+	}
+
+	_, err = c.db.Exec(ctx, query, state.ChatID, state.Name, state.Step, answers, state.ExpiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("upsert conversation: %w", err)
+	}
+
+	return nil
+}
+
+func (c *ConversationRepository) Delete(ctx context.Context, chatID int64) error {
+	const (
+		query = `DELETE FROM conversations WHERE chat_id = $1;`
+	)
+
+	_, err := c.db.Exec(ctx, query, chatID)
+	if err != nil {
+		return fmt.Errorf("delete conversation: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+type dbDelivery struct {
+	ID          int64        `db:"id"`
+	ArticleID   int64        `db:"article_id"`
+	Destination string       `db:"destination"`
+	ChatID      int64        `db:"chat_id"`
+	MessageID   int64        `db:"message_id"`
+	URL         string       `db:"url"`
+	CreatedDate time.Time    `db:"created_at"`
+	DeletedDate sql.NullTime `db:"deleted_at"`
+}
+
+type DeliveryRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDeliveryRepository(db *pgxpool.Pool) *DeliveryRepository {
+	return &DeliveryRepository{db: db}
+}
+
+func (d *DeliveryRepository) Store(ctx context.Context, delivery models.Delivery) error {
+	const (
+		query = `
+			INSERT INTO deliveries (article_id, destination, chat_id, message_id, url)
+			VALUES ($1, $2, $3, $4, $5);`
+	)
+
+	_, err := d.db.Exec(ctx, query,
+		delivery.ArticleID,
+		delivery.Destination,
+		delivery.ChatID,
+		delivery.MessageID,
+		delivery.URL,
+	)
+	if err != nil {
+		return fmt.Errorf("insert delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DeliveryRepository) DeliveriesByArticle(ctx context.Context, articleID int64) ([]*models.Delivery, error) {
+	const (
+		query = `
+			SELECT id, article_id, destination, chat_id, message_id, url, created_at, deleted_at
+			FROM deliveries
+			WHERE article_id = $1
+			ORDER BY created_at;`
+	)
+
+	rows, err := d.db.Query(ctx, query, articleID)
+	if err != nil {
+		return nil, fmt.Errorf("select deliveries: %w", err)
+	}
+
+	return scanDeliveries(rows)
+}
+
+// Latest returns a page of the most recent deliveries, optionally to one
+// destination, and the total number of them.
+func (d *DeliveryRepository) Latest(ctx context.Context, destination string, limit, offset uint64) ([]*models.Delivery, int, error) {
+	const (
+		filter = `
+			FROM deliveries
+			WHERE ($1 = '' OR destination = $1)`
+		query = `
+			SELECT id, article_id, destination, chat_id, message_id, url, created_at, deleted_at` + filter + `
+			ORDER BY created_at DESC, id DESC
+			LIMIT $2 OFFSET $3;`
+		countQuery = `SELECT COUNT(*)` + filter + `;`
+	)
+
+	var total int
+	if err := d.db.QueryRow(ctx, countQuery, destination).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count deliveries: %w", err)
+	}
+
+	rows, err := d.db.Query(ctx, query, destination, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("select deliveries: %w", err)
+	}
+
+	deliveries, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// Count returns the number of articles delivered to the destination since the
+// time.
+func (d *DeliveryRepository) Count(ctx context.Context, destination string, since time.Time) (int, error) {
+	const (
+		query = `SELECT COUNT(*) FROM deliveries WHERE destination = $1 AND created_at >= $2::TIMESTAMP;`
+	)
+
+	var count int
+	if err := d.db.QueryRow(ctx, query, destination, since.UTC().Format(time.RFC3339)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count deliveries: %w", err)
+	}
+
+	return count, nil
+}
+
+// MarkDeleted records that the delivered message is deleted.
+func (d *DeliveryRepository) MarkDeleted(ctx context.Context, id int64) error {
+	const (
+		query = `UPDATE deliveries SET deleted_at = NOW() WHERE id = $1;`
+	)
+
+	_, err := d.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("update delivery: %w", err)
+	}
+
+	return nil
+}
+
+func scanDeliveries(rows pgx.Rows) ([]*models.Delivery, error) {
+	defer rows.Close()
+
+	var deliveries []*models.Delivery
+	for rows.Next() {
+		var delivery dbDelivery
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.ArticleID,
+			&delivery.Destination,
+			&delivery.ChatID,
+			&delivery.MessageID,
+			&delivery.URL,
+			&delivery.CreatedDate,
+			&delivery.DeletedDate); err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, &models.Delivery{
+			ID:          delivery.ID,
+			ArticleID:   delivery.ArticleID,
+			Destination: delivery.Destination,
+			ChatID:      delivery.ChatID,
+			MessageID:   delivery.MessageID,
+			URL:         delivery.URL,
+			CreatedDate: delivery.CreatedDate,
+			DeletedDate: delivery.DeletedDate.Time,
+		})
+	}
+
+	return deliveries, rows.Err()
+}
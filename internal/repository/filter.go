@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FilterRepository stores the keywords of the articles skipped by the
+// fetcher.
+type FilterRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFilterRepository(db *pgxpool.Pool) *FilterRepository {
+	return &FilterRepository{db: db}
+}
+
+func (f *FilterRepository) Keywords(ctx context.Context) ([]string, error) {
+	const (
+		query = `SELECT keyword FROM filter_keywords ORDER BY keyword;`
+	)
+
+	rows, err := f.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("select filter keywords: %w", err)
+	}
+	defer rows.Close()
+
+	var keywords []string
+	for rows.Next() {
+		var keyword string
+		if err := rows.Scan(&keyword); err != nil {
+			return nil, err
+		}
+		keywords = append(keywords, keyword)
+	}
+
+	return keywords, rows.Err()
+}
+
+// SetKeywords replaces the keywords with the list.
+func (f *FilterRepository) SetKeywords(ctx context.Context, keywords []string) error {
+	const (
+		query = `
+			WITH deleted AS (
+				DELETE FROM filter_keywords WHERE keyword <> ALL($1)
+			)
+			INSERT INTO filter_keywords (keyword)
+			SELECT unnest($1::TEXT[])
+			ON CONFLICT (keyword) DO NOTHING;`
+	)
+
+	if keywords == nil {
+		keywords = []string{}
+	}
+	if _, err := f.db.Exec(ctx, query, keywords); err != nil {
+		return fmt.Errorf("update filter keywords: %w", err)
+	}
+
+	return nil
+}
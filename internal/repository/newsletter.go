@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/newsletter"
+)
+
+type dbNewsletterRecipient struct {
+	ID               int64        `db:"id"`
+	Email            string       `db:"email"`
+	Token            string       `db:"token"`
+	LastSentDate     sql.NullTime `db:"last_sent_at"`
+	UnsubscribedDate sql.NullTime `db:"unsubscribed_at"`
+	CreatedDate      time.Time    `db:"created_at"`
+}
+
+type NewsletterRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNewsletterRepository(db *pgxpool.Pool) *NewsletterRepository {
+	return &NewsletterRepository{db: db}
+}
+
+// AddRecipient adds the email with its unsubscribe token unless it is already
+// a recipient, so the unsubscribed ones stay unsubscribed.
+func (n *NewsletterRepository) AddRecipient(ctx context.Context, email, token string) error {
+	const (
+		query = `INSERT INTO newsletter_recipients (email, token) VALUES ($1, $2) ON CONFLICT (email) DO NOTHING;`
+	)
+
+	if _, err := n.db.Exec(ctx, query, email, token); err != nil {
+		return fmt.Errorf("insert newsletter recipient: %w", err)
+	}
+
+	return nil
+}
+
+// RetainRecipients removes the subscribed recipients which are not in the
+// list. The unsubscribed ones are kept to remember their choice.
+func (n *NewsletterRepository) RetainRecipients(ctx context.Context, emails []string) error {
+	const (
+		query = `DELETE FROM newsletter_recipients WHERE unsubscribed_at IS NULL AND email <> ALL($1);`
+	)
+
+	if _, err := n.db.Exec(ctx, query, emails); err != nil {
+		return fmt.Errorf("delete newsletter recipients: %w", err)
+	}
+
+	return nil
+}
+
+// DueRecipients returns the subscribed recipients whose last newsletter was
+// sent more than interval ago.
+func (n *NewsletterRepository) DueRecipients(ctx context.Context, interval time.Duration) ([]*models.NewsletterRecipient, error) {
+	const (
+		query = `
+			SELECT id, email, token, last_sent_at, unsubscribed_at, created_at
+			FROM newsletter_recipients
+			WHERE unsubscribed_at IS NULL
+			  AND (last_sent_at IS NULL OR last_sent_at <= $1::TIMESTAMP)
+			ORDER BY id;`
+	)
+
+	rows, err := n.db.Query(ctx, query, time.Now().Add(-interval).UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("select due newsletter recipients: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []*models.NewsletterRecipient
+	for rows.Next() {
+		var recipient dbNewsletterRecipient
+		if err := rows.Scan(
+			&recipient.ID,
+			&recipient.Email,
+			&recipient.Token,
+			&recipient.LastSentDate,
+			&recipient.UnsubscribedDate,
+			&recipient.CreatedDate); err != nil {
+			return nil, err
+		}
+
+		recipients = append(recipients, &models.NewsletterRecipient{
+			ID:               recipient.ID,
+			Email:            recipient.Email,
+			Token:            recipient.Token,
+			LastSentDate:     recipient.LastSentDate.Time,
+			UnsubscribedDate: recipient.UnsubscribedDate.Time,
+			CreatedDate:      recipient.CreatedDate,
+		})
+	}
+
+	return recipients, rows.Err()
+}
+
+func (n *NewsletterRepository) MarkSent(ctx context.Context, id int64) error {
+	const (
+		query = `UPDATE newsletter_recipients SET last_sent_at = NOW() WHERE id = $1;`
+	)
+
+	if _, err := n.db.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("update newsletter recipient: %w", err)
+	}
+
+	return nil
+}
+
+// Unsubscribe unsubscribes the recipient with the token. Unsubscribing twice
+// is not an error.
+func (n *NewsletterRepository) Unsubscribe(ctx context.Context, token string) error {
+	const (
+		query = `
+			UPDATE newsletter_recipients
+			SET unsubscribed_at = COALESCE(unsubscribed_at, NOW())
+			WHERE token = $1;`
+	)
+
+	tag, err := n.db.Exec(ctx, query, token)
+	if err != nil {
+		return fmt.Errorf("update newsletter recipient: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return newsletter.ErrRecipientNotFound
+	}
+
+	return nil
+}
@@ -19,6 +19,7 @@ type dbSource struct {
 	ID          int64     `db:"id"`
 	Name        string    `db:"name"`
 	URL         string    `db:"url"`
+	Priority    int       `db:"priority"`
 	CreatedDate time.Time `db:"created_at"`
 }
 
@@ -32,7 +33,7 @@ func NewSourceRepository(db *pgxpool.Pool) *SourceRepository {
 
 func (s *SourceRepository) Sources(ctx context.Context) ([]*models.Source, error) {
 	const (
-		query = `SELECT id, name, url, created_at FROM sources;`
+		query = `SELECT id, name, url, priority, created_at FROM sources;`
 	)
 
 	rows, err := s.db.Query(ctx, query)
@@ -44,7 +45,7 @@ func (s *SourceRepository) Sources(ctx context.Context) ([]*models.Source, error
 	var sources []*models.Source
 	for rows.Next() {
 		var source dbSource
-		if err := rows.Scan(&source.ID, &source.Name, &source.URL, &source.CreatedDate); err != nil {
+		if err := rows.Scan(&source.ID, &source.Name, &source.URL, &source.Priority, &source.CreatedDate); err != nil {
 			return nil, err
 		}
 
@@ -52,6 +53,7 @@ func (s *SourceRepository) Sources(ctx context.Context) ([]*models.Source, error
 			ID:          source.ID,
 			Name:        source.Name,
 			URL:         source.URL,
+			Priority:    source.Priority,
 			CreatedDate: source.CreatedDate,
 		})
 	}
@@ -61,11 +63,11 @@ func (s *SourceRepository) Sources(ctx context.Context) ([]*models.Source, error
 
 func (s *SourceRepository) SourceByID(ctx context.Context, id int64) (*models.Source, error) {
 	const (
-		query = `SELECT id, name, url, created_at FROM sources WHERE id = $1;`
+		query = `SELECT id, name, url, priority, created_at FROM sources WHERE id = $1;`
 	)
 
 	var source dbSource
-	err := s.db.QueryRow(ctx, query, id).Scan(&source.ID, &source.Name, &source.URL, &source.CreatedDate)
+	err := s.db.QueryRow(ctx, query, id).Scan(&source.ID, &source.Name, &source.URL, &source.Priority, &source.CreatedDate)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrorSourceNotFound
@@ -78,11 +80,11 @@ func (s *SourceRepository) SourceByID(ctx context.Context, id int64) (*models.So
 
 func (s *SourceRepository) Add(ctx context.Context, source models.Source) (int64, error) {
 	const (
-		query = `INSERT INTO sources (name, url) VALUES ($1, $2) RETURNING id;`
+		query = `INSERT INTO sources (name, url, priority) VALUES ($1, $2, $3) RETURNING id;`
 	)
 
 	var id int64
-	err := s.db.QueryRow(ctx, query, source.Name, source.URL).Scan(&id)
+	err := s.db.QueryRow(ctx, query, source.Name, source.URL, source.Priority).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("insert source: %w", err)
 	}
@@ -90,15 +92,36 @@ func (s *SourceRepository) Add(ctx context.Context, source models.Source) (int64
 	return id, nil
 }
 
+// Update changes the name, URL and priority of the source.
+func (s *SourceRepository) Update(ctx context.Context, source models.Source) error {
+	const (
+		query = `UPDATE sources SET name = $2, url = $3, priority = $4 WHERE id = $1;`
+	)
+
+	tag, err := s.db.Exec(ctx, query, source.ID, source.Name, source.URL, source.Priority)
+	if err != nil {
+		return fmt.Errorf("update source: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrorSourceNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes the source with its articles.
 func (s *SourceRepository) Delete(ctx context.Context, id int64) error {
 	const (
 		query = `DELETE FROM sources WHERE id = $1;`
 	)
 
-	_, err := s.db.Exec(ctx, query, id)
+	tag, err := s.db.Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("delete source: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return ErrorSourceNotFound
+	}
 
 	return nil
 }
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+var (
+	ErrorSubscriptionNotFound = errors.New("subscription not found")
+)
+
+type dbSubscription struct {
+	ID          int64     `db:"id"`
+	ChatID      int64     `db:"chat_id"`
+	Kind        string    `db:"kind"`
+	Value       string    `db:"value"`
+	CreatedDate time.Time `db:"created_at"`
+}
+
+type SubscriptionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSubscriptionRepository(db *pgxpool.Pool) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db}
+}
+
+func (s *SubscriptionRepository) Subscribe(ctx context.Context, subscription models.Subscription) (int64, error) {
+	const (
+		subscriberQuery = `INSERT INTO subscribers (chat_id) VALUES ($1) ON CONFLICT DO NOTHING;`
+		query           = `
+			INSERT INTO subscriptions (chat_id, kind, value)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (chat_id, kind, value) DO UPDATE SET kind = EXCLUDED.kind
+			RETURNING id;`
+	)
+
+	if _, err := s.db.Exec(ctx, subscriberQuery, subscription.ChatID); err != nil {
+		return 0, fmt.Errorf("insert subscriber: %w", err)
+	}
+
+	var id int64
+	err := s.db.QueryRow(ctx, query, subscription.ChatID, subscription.Kind, subscription.Value).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("insert subscription: %w", err)
+	}
+
+	return id, nil
+}
+
+func (s *SubscriptionRepository) Subscriptions(ctx context.Context, chatID int64) ([]*models.Subscription, error) {
+	const (
+		query = `
+			SELECT id, chat_id, kind, value, created_at
+			FROM subscriptions
+			WHERE chat_id = $1
+			ORDER BY id;`
+	)
+
+	rows, err := s.db.Query(ctx, query, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("select subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.Subscription
+	for rows.Next() {
+		var subscription dbSubscription
+		if err := rows.Scan(
+			&subscription.ID,
+			&subscription.ChatID,
+			&subscription.Kind,
+			&subscription.Value,
+			&subscription.CreatedDate); err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, (*models.Subscription)(&subscription))
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func (s *SubscriptionRepository) Unsubscribe(ctx context.Context, chatID, id int64) error {
+	const (
+		query = `DELETE FROM subscriptions WHERE chat_id = $1 AND id = $2;`
+	)
+
+	tag, err := s.db.Exec(ctx, query, chatID, id)
+	if err != nil {
+		return fmt.Errorf("delete subscription: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrorSubscriptionNotFound
+	}
+
+	return nil
+}
+
+func (s *SubscriptionRepository) Subscriber(ctx context.Context, chatID int64) (*models.Subscriber, error) {
+	const (
+		query = `SELECT frequency, last_digest_at FROM subscribers WHERE chat_id = $1;`
+	)
+
+	subscriber := models.Subscriber{
+		ChatID:    chatID,
+		Frequency: models.DeliveryFrequencyInstant,
+	}
+
+	var lastDigest *time.Time
+	if err := s.db.QueryRow(ctx, query, chatID).Scan(&subscriber.Frequency, &lastDigest); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &subscriber, nil
+		}
+		return nil, fmt.Errorf("select subscriber: %w", err)
+	}
+	if lastDigest != nil {
+		subscriber.LastDigestDate = *lastDigest
+	}
+
+	return &subscriber, nil
+}
+
+func (s *SubscriptionRepository) SetFrequency(ctx context.Context, chatID int64, frequency string) error {
+	const (
+		query = `
+			INSERT INTO subscribers (chat_id, frequency)
+			VALUES ($1, $2)
+			ON CONFLICT (chat_id) DO UPDATE SET frequency = EXCLUDED.frequency;`
+	)
+
+	if _, err := s.db.Exec(ctx, query, chatID, frequency); err != nil {
+		return fmt.Errorf("update subscriber frequency: %w", err)
+	}
+
+	return nil
+}
+
+// Enqueue adds the articles stored since the given time to the delivery queue
+// of every chat with a matching subscription. Articles stored before the
+// subscription was created are skipped.
+func (s *SubscriptionRepository) Enqueue(ctx context.Context, since time.Time) error {
+	const (
+		query = `
+			INSERT INTO subscription_deliveries (chat_id, article_id)
+			SELECT DISTINCT s.chat_id, a.id
+			FROM subscriptions s
+			JOIN articles a ON
+				(s.kind = 'source' AND s.value = a.source_id::TEXT)
+				OR (s.kind = 'tag' AND EXISTS (SELECT 1 FROM unnest(a.tags) t WHERE LOWER(t) = LOWER(s.value)))
+				OR (s.kind = 'query' AND (
+					POSITION(LOWER(s.value) IN LOWER(a.title)) > 0
+					OR POSITION(LOWER(s.value) IN LOWER(a.summary)) > 0))
+			WHERE a.created_at >= GREATEST(s.created_at, $1::TIMESTAMP)
+			ON CONFLICT DO NOTHING;`
+	)
+
+	if _, err := s.db.Exec(ctx, query, since.UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("insert subscription deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// PendingInstant returns the queued articles of chats receiving them instantly.
+func (s *SubscriptionRepository) PendingInstant(ctx context.Context, limit uint64) ([]*models.SubscriptionDelivery, error) {
+	const (
+		query = `
+			SELECT d.chat_id, d.queued_at, a.*
+			FROM subscription_deliveries d
+			JOIN subscribers s ON s.chat_id = d.chat_id
+			JOIN (SELECT ` + articleColumns + ` FROM articles) a ON a.id = d.article_id
+			WHERE d.delivered_at IS NULL AND s.frequency = 'instant'
+			ORDER BY d.queued_at
+			LIMIT $1;`
+	)
+
+	return s.pending(ctx, query, limit)
+}
+
+// Pending returns the queued articles of a chat.
+func (s *SubscriptionRepository) Pending(ctx context.Context, chatID int64, limit uint64) ([]*models.SubscriptionDelivery, error) {
+	const (
+		query = `
+			SELECT d.chat_id, d.queued_at, a.*
+			FROM subscription_deliveries d
+			JOIN (SELECT ` + articleColumns + ` FROM articles) a ON a.id = d.article_id
+			WHERE d.delivered_at IS NULL AND d.chat_id = $1
+			ORDER BY a.published_at DESC
+			LIMIT $2;`
+	)
+
+	return s.pending(ctx, query, chatID, limit)
+}
+
+func (s *SubscriptionRepository) pending(ctx context.Context, query string, args ...any) ([]*models.SubscriptionDelivery, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select subscription deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.SubscriptionDelivery
+	for rows.Next() {
+		var (
+			delivery models.SubscriptionDelivery
+			article  dbArticle
+		)
+		dest := append([]any{&delivery.ChatID, &delivery.QueuedDate}, article.scanDest()...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		delivery.Article = article.model()
+		deliveries = append(deliveries, &delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// DueDigests returns the chats receiving digests whose last digest was sent
+// more than interval ago.
+func (s *SubscriptionRepository) DueDigests(ctx context.Context, interval time.Duration) ([]int64, error) {
+	const (
+		query = `
+			SELECT s.chat_id
+			FROM subscribers s
+			WHERE s.frequency = 'digest'
+			  AND (s.last_digest_at IS NULL OR s.last_digest_at <= $1::TIMESTAMP)
+			  AND EXISTS (
+			      SELECT 1 FROM subscription_deliveries d
+			      WHERE d.chat_id = s.chat_id AND d.delivered_at IS NULL);`
+	)
+
+	rows, err := s.db.Query(ctx, query, time.Now().Add(-interval).UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("select due digests: %w", err)
+	}
+	defer rows.Close()
+
+	var chatIDs []int64
+	for rows.Next() {
+		var chatID int64
+		if err := rows.Scan(&chatID); err != nil {
+			return nil, err
+		}
+		chatIDs = append(chatIDs, chatID)
+	}
+
+	return chatIDs, rows.Err()
+}
+
+func (s *SubscriptionRepository) MarkDelivered(ctx context.Context, chatID int64, articleIDs ...int64) error {
+	const (
+		query = `
+			UPDATE subscription_deliveries SET delivered_at = NOW()
+			WHERE chat_id = $1 AND article_id = ANY($2);`
+	)
+
+	if _, err := s.db.Exec(ctx, query, chatID, articleIDs); err != nil {
+		return fmt.Errorf("update subscription deliveries: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SubscriptionRepository) MarkDigestSent(ctx context.Context, chatID int64) error {
+	const (
+		query = `UPDATE subscribers SET last_digest_at = NOW() WHERE chat_id = $1;`
+	)
+
+	if _, err := s.db.Exec(ctx, query, chatID); err != nil {
+		return fmt.Errorf("update subscriber: %w", err)
+	}
+
+	return nil
+}
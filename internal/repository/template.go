@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+var (
+	ErrorTemplateNotFound = errors.New("template not found")
+)
+
+type dbPostTemplate struct {
+	ID          int64     `db:"id"`
+	Scope       string    `db:"scope"`
+	TargetID    int64     `db:"target_id"`
+	ParseMode   string    `db:"parse_mode"`
+	Body        string    `db:"body"`
+	UpdatedDate time.Time `db:"updated_at"`
+}
+
+type TemplateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTemplateRepository(db *pgxpool.Pool) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+// SetTemplate creates or replaces the template of the scope and target.
+func (t *TemplateRepository) SetTemplate(ctx context.Context, template models.PostTemplate) error {
+	const (
+		query = `
+			INSERT INTO post_templates (scope, target_id, parse_mode, body)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (scope, target_id) DO UPDATE
+			SET parse_mode = EXCLUDED.parse_mode, body = EXCLUDED.body, updated_at = NOW();`
+	)
+
+	_, err := t.db.Exec(ctx, query, template.Scope, template.TargetID, template.ParseMode, template.Body)
+	if err != nil {
+		return fmt.Errorf("upsert template: %w", err)
+	}
+
+	return nil
+}
+
+// Template returns the template of the scope and target, or nil without an
+// error if there is none.
+func (t *TemplateRepository) Template(ctx context.Context, scope string, targetID int64) (*models.PostTemplate, error) {
+	const (
+		query = `
+			SELECT id, scope, target_id, parse_mode, body, updated_at
+			FROM post_templates
+			WHERE scope = $1 AND target_id = $2;`
+	)
+
+	var template dbPostTemplate
+	err := t.db.QueryRow(ctx, query, scope, targetID).Scan(
+		&template.ID,
+		&template.Scope,
+		&template.TargetID,
+		&template.ParseMode,
+		&template.Body,
+		&template.UpdatedDate,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("select template: %w", err)
+	}
+
+	return (*models.PostTemplate)(&template), nil
+}
+
+func (t *TemplateRepository) DeleteTemplate(ctx context.Context, scope string, targetID int64) error {
+	const (
+		query = `DELETE FROM post_templates WHERE scope = $1 AND target_id = $2;`
+	)
+
+	tag, err := t.db.Exec(ctx, query, scope, targetID)
+	if err != nil {
+		return fmt.Errorf("delete template: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrorTemplateNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+type VoteRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewVoteRepository(db *pgxpool.Pool) *VoteRepository {
+	return &VoteRepository{db: db}
+}
+
+// Vote stores the vote of the user for the article. Voting the same way twice
+// withdraws the vote. It returns the resulting votes of the article.
+func (v *VoteRepository) Vote(ctx context.Context, articleID, userID int64, value int) (models.Votes, error) {
+	const (
+		deleteQuery = `DELETE FROM article_votes WHERE article_id = $1 AND user_id = $2 AND value = $3;`
+		upsertQuery = `
+			INSERT INTO article_votes (article_id, user_id, value)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (article_id, user_id) DO UPDATE
+			SET value = EXCLUDED.value, updated_at = NOW();`
+	)
+
+	err := pgx.BeginFunc(ctx, v.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, deleteQuery, articleID, userID, value)
+		if err != nil {
+			return fmt.Errorf("delete vote: %w", err)
+		}
+		if tag.RowsAffected() > 0 {
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx, upsertQuery, articleID, userID, value); err != nil {
+			return fmt.Errorf("upsert vote: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Votes{}, err
+	}
+
+	return v.ArticleVotes(ctx, articleID)
+}
+
+func (v *VoteRepository) ArticleVotes(ctx context.Context, articleID int64) (models.Votes, error) {
+	const (
+		query = `
+			SELECT COUNT(*) FILTER (WHERE value > 0), COUNT(*) FILTER (WHERE value < 0)
+			FROM article_votes
+			WHERE article_id = $1;`
+	)
+
+	var votes models.Votes
+	if err := v.db.QueryRow(ctx, query, articleID).Scan(&votes.Up, &votes.Down); err != nil {
+		return models.Votes{}, fmt.Errorf("select article votes: %w", err)
+	}
+
+	return votes, nil
+}
+
+// SourceQuality returns the votes and the quality score in [0, 1] of the
+// sources which have votes.
+func (v *VoteRepository) SourceQuality(ctx context.Context) (map[int64]models.SourceQuality, error) {
+	const (
+		query = `SELECT source_id, upvotes, downvotes, score FROM source_quality;`
+	)
+
+	rows, err := v.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("select source quality: %w", err)
+	}
+	defer rows.Close()
+
+	quality := make(map[int64]models.SourceQuality)
+	for rows.Next() {
+		var (
+			sourceID int64
+			q        models.SourceQuality
+		)
+		if err := rows.Scan(&sourceID, &q.Votes.Up, &q.Votes.Down, &q.Score); err != nil {
+			return nil, err
+		}
+		quality[sourceID] = q
+	}
+
+	return quality, rows.Err()
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+type WebhookRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookRepository(db *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (w *WebhookRepository) StoreDeadLetter(ctx context.Context, letter models.WebhookDeadLetter) error {
+	const (
+		query = `
+			INSERT INTO webhook_dead_letters (event_id, event, url, payload, attempts, last_error)
+			VALUES ($1, $2, $3, $4, $5, $6);`
+	)
+
+	_, err := w.db.Exec(ctx, query,
+		letter.EventID,
+		letter.Event,
+		letter.URL,
+		string(letter.Payload),
+		letter.Attempts,
+		letter.LastError,
+	)
+	if err != nil {
+		return fmt.Errorf("insert webhook dead letter: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package retry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const errorBodyMaxLen = 512
+
+// StatusError returns the error of the response with an unexpected status,
+// including the beginning of the body, which usually describes the error.
+func StatusError(resp *http.Response) error {
+	err := fmt.Errorf("unexpected status %s", resp.Status)
+	if body, _ := io.ReadAll(io.LimitReader(resp.Body, errorBodyMaxLen)); len(bytes.TrimSpace(body)) > 0 {
+		err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(body))
+	}
+	return err
+}
+
+// RetryableStatus reports whether the request may succeed if it is made
+// again: on server errors, rate limits and timeouts.
+func RetryableStatus(code int) bool {
+	return code >= http.StatusInternalServerError ||
+		code == http.StatusTooManyRequests ||
+		code == http.StatusRequestTimeout
+}
+
+// RetryAfter parses the Retry-After header in seconds, which some services
+// send as a fraction. It returns zero, i.e. the backoff delay, if the header
+// is missing or invalid, and at most maxWait otherwise.
+func RetryAfter(header string, maxWait time.Duration) time.Duration {
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return min(time.Duration(seconds*float64(time.Second)), maxWait)
+}
@@ -0,0 +1,87 @@
+// Package retry retries failed requests with exponential backoff. It is
+// shared by the Telegram sender, the publishers and the webhook dispatcher.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Policy is how many times a request is attempted and how long to wait
+// between the attempts.
+type Policy struct {
+	MaxAttempts int
+	// Delay is the wait before the first retry, it doubles with every
+	// attempt up to MaxDelay.
+	Delay    time.Duration
+	MaxDelay time.Duration
+	// OnRetry is called, if set, before waiting for the next attempt.
+	OnRetry func(err error, attempt int, wait time.Duration)
+}
+
+// retryableError marks the error of a request which may be retried.
+type retryableError struct {
+	err  error
+	wait time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// After marks the error as retryable after the wait, or after the backoff
+// delay of the policy if the wait is zero. Other errors are not retried.
+func After(err error, wait time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err, wait: wait}
+}
+
+// Do calls the request until it succeeds, fails with an error which is not
+// retryable or the attempts of the policy run out. It returns the number of
+// attempts made and the error of the last one.
+func Do(ctx context.Context, p Policy, request func(ctx context.Context) error) (int, error) {
+	delay := p.Delay
+
+	for attempt := 1; ; attempt++ {
+		err := request(ctx)
+		if err == nil {
+			return attempt, nil
+		}
+
+		var retryable *retryableError
+		if !errors.As(err, &retryable) {
+			return attempt, err
+		}
+		err = retryable.err
+
+		if attempt >= p.MaxAttempts {
+			return attempt, err
+		}
+
+		wait := retryable.wait
+		if wait == 0 {
+			wait = delay
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(err, attempt, wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		delay = min(delay*2, p.MaxDelay)
+	}
+}
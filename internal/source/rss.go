@@ -3,6 +3,7 @@ package source
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/SlyMarbo/rss"
 	"github.com/to77e/news-fetching-bot/internal/models"
@@ -36,6 +37,8 @@ func (r RSSSource) Fetch(ctx context.Context) ([]models.Item, error) {
 			Link:       v.Link,
 			Date:       v.Date,
 			Summary:    v.Summary,
+			Author:     feed.Author,
+			ImageURL:   itemImageURL(v),
 			SourceName: r.SourceName,
 		})
 	}
@@ -68,6 +71,20 @@ func (r RSSSource) loadFeed(ctx context.Context, url string) (*rss.Feed, error)
 	}
 }
 
+// itemImageURL returns the URL of the first image enclosure or of the image
+// of the item.
+func itemImageURL(item *rss.Item) string {
+	for _, v := range item.Enclosures {
+		if v != nil && strings.HasPrefix(v.Type, "image/") {
+			return v.URL
+		}
+	}
+	if item.Image != nil {
+		return item.Image.URL
+	}
+	return ""
+}
+
 func (r RSSSource) ID() int64 {
 	return r.SourceID
 }
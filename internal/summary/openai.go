@@ -4,19 +4,39 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/sashabaranov/go-openai"
+	"github.com/to77e/news-fetching-bot/internal/metrics"
+	"github.com/to77e/news-fetching-bot/internal/models"
 )
 
+const (
+	operationSummarize = "summarize"
+	operationRate      = "rate"
+)
+
+const defaultRelevancePrompt = "Rate how interesting and relevant the following news article is " +
+	"for the readers of a tech news channel on a scale from 0 to 10. Reply with the number only."
+
 type OpenAISummarizer struct {
-	client  *openai.Client
-	prompt  string
-	model   string
-	enabled bool
-	mu      sync.Mutex
+	client          *openai.Client
+	prompt          string
+	relevancePrompt string
+	model           string
+	enabled         bool
+	mu              sync.Mutex
+
+	// promptPrice and completionPrice are the prices of 1000 tokens in
+	// dollars used to estimate the cost of the requests
+	promptPrice     float64
+	completionPrice float64
+
+	statusMu sync.Mutex
+	status   models.SummarizerStatus
 }
 
 func NewOpenAISummarizer(apiKey, prompt, model string) *OpenAISummarizer {
@@ -35,6 +55,43 @@ func NewOpenAISummarizer(apiKey, prompt, model string) *OpenAISummarizer {
 	return s
 }
 
+// Status returns whether the summarizer is enabled and the outcome of its
+// last requests to the API.
+func (s *OpenAISummarizer) Status() models.SummarizerStatus {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	status := s.status
+	status.Enabled = s.enabled
+	status.Model = s.model
+
+	return status
+}
+
+func (s *OpenAISummarizer) record(err error) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	if err != nil {
+		s.status.LastFailureDate = time.Now()
+		s.status.LastError = err.Error()
+		return
+	}
+	s.status.LastSuccessDate = time.Now()
+}
+
+// SetPrices sets the prices of 1000 prompt and completion tokens of the model
+// in dollars.
+func (s *OpenAISummarizer) SetPrices(prompt, completion float64) {
+	s.promptPrice = prompt
+	s.completionPrice = completion
+}
+
+// SetRelevancePrompt overrides the prompt used by Rate.
+func (s *OpenAISummarizer) SetRelevancePrompt(prompt string) {
+	s.relevancePrompt = prompt
+}
+
 func (s *OpenAISummarizer) Summarize(ctx context.Context, text string) (string, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -63,7 +120,7 @@ func (s *OpenAISummarizer) Summarize(ctx context.Context, text string) (string,
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	resp, err := s.client.CreateChatCompletion(ctx, request)
+	resp, err := s.complete(ctx, operationSummarize, request)
 	if err != nil {
 		if strings.Contains(err.Error(), "status code: 429") {
 			slog.Warn("openai summarizer", "rate limit exceeded", err)
@@ -85,3 +142,80 @@ func (s *OpenAISummarizer) Summarize(ctx context.Context, text string) (string,
 
 	return strings.Join(sentences[:len(sentences)-1], ".") + ".", nil
 }
+
+// Rate asks the model how relevant the text is for the channel using the
+// relevance prompt and returns the answer scaled to [0, 1]. It returns 0 when
+// the summarizer is disabled.
+func (s *OpenAISummarizer) Rate(ctx context.Context, text string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.enabled {
+		return 0, nil
+	}
+
+	prompt := s.relevancePrompt
+	if prompt == "" {
+		prompt = defaultRelevancePrompt
+	}
+
+	request := openai.ChatCompletionRequest{
+		Model: s.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: prompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: text,
+			},
+		},
+		MaxTokens:   8,
+		Temperature: 0,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	resp, err := s.complete(ctx, operationRate, request)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create chat completion: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return 0, fmt.Errorf("no choices in openai response")
+	}
+
+	raw := strings.TrimSpace(resp.Choices[0].Message.Content)
+	rating, err := strconv.ParseFloat(strings.TrimRight(raw, "."), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse rating %q: %w", raw, err)
+	}
+
+	return min(max(rating, 0), 10) / 10, nil
+}
+
+// complete sends the request and records its outcome, duration and the used
+// tokens.
+func (s *OpenAISummarizer) complete(
+	ctx context.Context,
+	operation string,
+	request openai.ChatCompletionRequest,
+) (openai.ChatCompletionResponse, error) {
+	start := time.Now()
+	resp, err := s.client.CreateChatCompletion(ctx, request)
+	metrics.SummarizerDuration.WithLabelValues(operation, metrics.Result(err)).Observe(time.Since(start).Seconds())
+	s.record(err)
+	if err != nil {
+		return resp, err
+	}
+
+	metrics.SummarizerTokens.WithLabelValues(operation, metrics.TokensPrompt).Add(float64(resp.Usage.PromptTokens))
+	metrics.SummarizerTokens.WithLabelValues(operation, metrics.TokensCompletion).Add(float64(resp.Usage.CompletionTokens))
+	metrics.SummarizerCost.WithLabelValues(operation).Add(
+		(float64(resp.Usage.PromptTokens)*s.promptPrice + float64(resp.Usage.CompletionTokens)*s.completionPrice) / 1000,
+	)
+
+	return resp, nil
+}
@@ -0,0 +1,277 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+	"github.com/to77e/news-fetching-bot/internal/retry"
+)
+
+const (
+	IDHeader        = "X-Webhook-ID"
+	EventHeader     = "X-Webhook-Event"
+	TimestampHeader = "X-Webhook-Timestamp"
+	// SignatureHeader is "sha256=" followed by the hex HMAC-SHA256 of the
+	// timestamp, a dot and the body, keyed with the secret.
+	SignatureHeader = "X-Webhook-Signature"
+
+	defaultMaxAttempts = 5
+	defaultRetryDelay  = time.Second
+	maxRetryDelay      = 5 * time.Minute
+
+	requestTimeout = 10 * time.Second
+	storeTimeout   = 5 * time.Second
+	queueSize      = 1000
+	workers        = 4
+
+	// enqueueTimeout is how long the fetcher and the notifier wait for room
+	// in the queue before the event is stored as a dead letter.
+	enqueueTimeout = 5 * time.Second
+)
+
+var errQueueFull = errors.New("queue is full")
+
+type DeadLetterStorage interface {
+	StoreDeadLetter(ctx context.Context, letter models.WebhookDeadLetter) error
+}
+
+// delivery is an event to post to one of the webhooks.
+type delivery struct {
+	url     string
+	event   Event
+	payload []byte
+}
+
+// Dispatcher posts the events to the webhooks in the background, retrying
+// failed requests with exponential backoff. Events which can't be delivered
+// are stored as dead letters.
+type Dispatcher struct {
+	urls        []string
+	secret      string
+	events      map[string]bool
+	deadLetters DeadLetterStorage
+	client      *http.Client
+
+	maxAttempts int
+	retryDelay  time.Duration
+
+	queue chan delivery
+}
+
+func NewDispatcher(urls []string, secret string, events []string, deadLetters DeadLetterStorage) *Dispatcher {
+	subscribed := make(map[string]bool, len(events))
+	for _, v := range events {
+		subscribed[strings.TrimSpace(v)] = true
+	}
+
+	return &Dispatcher{
+		urls:        urls,
+		secret:      secret,
+		events:      subscribed,
+		deadLetters: deadLetters,
+		client:      &http.Client{Timeout: requestTimeout},
+		maxAttempts: defaultMaxAttempts,
+		retryDelay:  defaultRetryDelay,
+		queue:       make(chan delivery, queueSize),
+	}
+}
+
+// SetRetries sets how many times a delivery is attempted and the delay before
+// the first retry, which doubles with every attempt.
+func (d *Dispatcher) SetRetries(maxAttempts int, retryDelay time.Duration) {
+	d.maxAttempts = maxAttempts
+	d.retryDelay = retryDelay
+}
+
+func (d *Dispatcher) ArticleStored(ctx context.Context, article models.Article) {
+	d.publish(ctx, EventArticleStored, articleData(&article))
+}
+
+func (d *Dispatcher) ArticlePosted(ctx context.Context, article *models.Article) {
+	d.publish(ctx, EventArticlePosted, articleData(article))
+}
+
+func (d *Dispatcher) SourceFailed(ctx context.Context, source *models.Source, err error) {
+	d.publish(ctx, EventSourceFailed, SourceFailedData{
+		SourceID: source.ID,
+		Name:     source.Name,
+		URL:      source.URL,
+		Error:    err.Error(),
+	})
+}
+
+// Start delivers the events until ctx is done. The events left in the queue
+// are stored as dead letters.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case v := <-d.queue:
+					d.deliver(ctx, v)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for {
+		select {
+		case v := <-d.queue:
+			d.storeDeadLetter(v, 0, ctx.Err())
+		default:
+			return ctx.Err()
+		}
+	}
+}
+
+func (d *Dispatcher) publish(ctx context.Context, eventType string, data any) {
+	if !d.events[eventType] {
+		return
+	}
+
+	id, err := newEventID()
+	if err != nil {
+		slog.With("error", err.Error()).ErrorContext(ctx, "generate webhook event id")
+		return
+	}
+
+	event := Event{ID: id, Type: eventType, CreatedAt: time.Now().UTC(), Data: data}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.With("error", err.Error()).ErrorContext(ctx, "marshal webhook event", "event", eventType)
+		return
+	}
+
+	for _, url := range d.urls {
+		d.enqueue(ctx, delivery{url: url, event: event, payload: payload})
+	}
+}
+
+// enqueue queues the delivery, waiting at most enqueueTimeout for room in the
+// queue, so slow webhooks hold up the fetcher and the notifier only briefly.
+func (d *Dispatcher) enqueue(ctx context.Context, v delivery) {
+	select {
+	case d.queue <- v:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(enqueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case d.queue <- v:
+	case <-timer.C:
+		d.storeDeadLetter(v, 0, errQueueFull)
+	case <-ctx.Done():
+		d.storeDeadLetter(v, 0, fmt.Errorf("%w: %w", ctx.Err(), errQueueFull))
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, v delivery) {
+	policy := retry.Policy{
+		MaxAttempts: d.maxAttempts,
+		Delay:       d.retryDelay,
+		MaxDelay:    maxRetryDelay,
+		OnRetry: func(err error, attempt int, wait time.Duration) {
+			slog.With("error", err.Error()).WarnContext(ctx, "webhook delivery failed, retrying",
+				"event", v.event.ID, "attempt", attempt, "wait", wait)
+		},
+	}
+
+	attempts, err := retry.Do(ctx, policy, func(ctx context.Context) error {
+		return d.post(ctx, v)
+	})
+	if err != nil {
+		d.storeDeadLetter(v, attempts, err)
+	}
+}
+
+// post makes a single attempt to deliver the event. The errors after which
+// the delivery may succeed are marked as retryable.
+func (d *Dispatcher) post(ctx context.Context, v delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, bytes.NewReader(v.payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, v.event.ID)
+	req.Header.Set(EventHeader, v.event.Type)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(d.secret, timestamp, v.payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		// network errors
+		return retry.After(err, 0)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	err = retry.StatusError(resp)
+	if retry.RetryableStatus(resp.StatusCode) {
+		return retry.After(err, retry.RetryAfter(resp.Header.Get("Retry-After"), maxRetryDelay))
+	}
+	return err
+}
+
+func (d *Dispatcher) storeDeadLetter(v delivery, attempts int, err error) {
+	slog.With("error", err.Error()).Error("webhook delivery failed", "event", v.event.ID, "url", v.url)
+
+	// the dispatcher may be stopping, so the context of the delivery is not used
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := d.deadLetters.StoreDeadLetter(ctx, models.WebhookDeadLetter{
+		EventID:   v.event.ID,
+		Event:     v.event.Type,
+		URL:       v.url,
+		Payload:   v.payload,
+		Attempts:  attempts,
+		LastError: err.Error(),
+	}); err != nil {
+		slog.With("error", err.Error()).Error("store webhook dead letter", "event", v.event.ID)
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 signature of the payload sent at the
+// timestamp, which receivers compute to verify the SignatureHeader.
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newEventID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+type deadLetters struct {
+	mu      sync.Mutex
+	letters []models.WebhookDeadLetter
+}
+
+func (s *deadLetters) StoreDeadLetter(_ context.Context, letter models.WebhookDeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.letters = append(s.letters, letter)
+	return nil
+}
+
+func TestSign(t *testing.T) {
+	const want = "086f6aff7bd084c98679825129c5a64dbad88c760016d6d2c0fb123f27951d54"
+
+	if got := Sign("secret", "1700000000", []byte(`{"id":"1"}`)); got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+	if got := Sign("other", "1700000000", []byte(`{"id":"1"}`)); got == want {
+		t.Error("Sign() doesn't depend on the secret")
+	}
+	if got := Sign("secret", "1700000001", []byte(`{"id":"1"}`)); got == want {
+		t.Error("Sign() doesn't depend on the timestamp")
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	tests := []struct {
+		name            string
+		statuses        []int
+		wantRequests    int32
+		wantDeadLetters int
+	}{
+		{name: "delivered", statuses: []int{http.StatusOK}, wantRequests: 1},
+		{name: "delivered after retries", statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusNoContent}, wantRequests: 3},
+		{name: "dead letter after the attempts", statuses: []int{http.StatusInternalServerError}, wantRequests: 3, wantDeadLetters: 1},
+		{name: "dead letter without retries", statuses: []int{http.StatusBadRequest}, wantRequests: 1, wantDeadLetters: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+
+				timestamp := r.Header.Get(TimestampHeader)
+				body, _ := io.ReadAll(r.Body)
+				if got, want := r.Header.Get(SignatureHeader), "sha256="+Sign("secret", timestamp, body); got != want {
+					t.Errorf("signature = %s, want %s", got, want)
+				}
+
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses))-1])
+			}))
+			defer server.Close()
+
+			storage := &deadLetters{}
+			d := NewDispatcher([]string{server.URL}, "secret", Events, storage)
+			d.SetRetries(3, time.Millisecond)
+
+			v := delivery{
+				url:     server.URL,
+				event:   Event{ID: "1", Type: EventArticleStored},
+				payload: []byte(`{"id":"1"}`),
+			}
+			d.deliver(context.Background(), v)
+
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+			if len(storage.letters) != tt.wantDeadLetters {
+				t.Fatalf("dead letters = %d, want %d", len(storage.letters), tt.wantDeadLetters)
+			}
+			if tt.wantDeadLetters > 0 {
+				letter := storage.letters[0]
+				if letter.Attempts != int(tt.wantRequests) || letter.EventID != "1" || letter.URL != server.URL {
+					t.Errorf("dead letter = %+v, want %d attempts of event 1 to %s", letter, tt.wantRequests, server.URL)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/to77e/news-fetching-bot/internal/models"
+)
+
+const (
+	EventArticleStored = "article.stored"
+	EventArticlePosted = "article.posted"
+	EventSourceFailed  = "source.failed"
+)
+
+// Events are all the events webhooks can subscribe to.
+var Events = []string{EventArticleStored, EventArticlePosted, EventSourceFailed}
+
+// Event is the JSON payload posted to the webhooks.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// ArticleData is the data of the article events.
+type ArticleData struct {
+	ID          int64      `json:"id"`
+	SourceID    int64      `json:"source_id"`
+	Title       string     `json:"title"`
+	Link        string     `json:"link"`
+	Summary     string     `json:"summary"`
+	Tags        []string   `json:"tags"`
+	Author      string     `json:"author,omitempty"`
+	ImageURL    string     `json:"image_url,omitempty"`
+	PublishedAt time.Time  `json:"published_at"`
+	PostedAt    *time.Time `json:"posted_at,omitempty"`
+	PostSummary string     `json:"post_summary,omitempty"`
+}
+
+// SourceFailedData is the data of the source.failed event.
+type SourceFailedData struct {
+	SourceID int64  `json:"source_id"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Error    string `json:"error"`
+}
+
+func articleData(article *models.Article) ArticleData {
+	data := ArticleData{
+		ID:          article.ID,
+		SourceID:    article.SourceID,
+		Title:       article.Title,
+		Link:        article.Link,
+		Summary:     article.Summary,
+		Tags:        article.Tags,
+		Author:      article.Author,
+		ImageURL:    article.ImageURL,
+		PublishedAt: article.PublishedDate,
+		PostSummary: article.PostSummary,
+	}
+	if data.Tags == nil {
+		data.Tags = []string{}
+	}
+	if !article.PostedDate.IsZero() {
+		data.PostedAt = &article.PostedDate
+	}
+	return data
+}
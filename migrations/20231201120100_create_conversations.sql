@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE conversations
+(
+    chat_id    BIGINT PRIMARY KEY,
+    name       TEXT      NOT NULL,
+    step       INT       NOT NULL DEFAULT 0,
+    answers    JSONB     NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS conversations;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE articles
+    ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS tags;
+-- +goose StatementEnd
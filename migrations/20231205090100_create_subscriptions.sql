@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE subscribers
+(
+    chat_id        BIGINT PRIMARY KEY,
+    frequency      TEXT      NOT NULL DEFAULT 'instant',
+    last_digest_at TIMESTAMP,
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_subscribers_frequency CHECK (frequency IN ('instant', 'digest'))
+);
+
+CREATE TABLE subscriptions
+(
+    id         SERIAL PRIMARY KEY,
+    chat_id    BIGINT    NOT NULL,
+    kind       TEXT      NOT NULL,
+    value      TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_subscriptions_chat_id
+        FOREIGN KEY (chat_id)
+            REFERENCES subscribers (chat_id)
+            ON DELETE CASCADE,
+    CONSTRAINT chk_subscriptions_kind CHECK (kind IN ('source', 'tag', 'query')),
+    CONSTRAINT uq_subscriptions UNIQUE (chat_id, kind, value)
+);
+
+CREATE TABLE subscription_deliveries
+(
+    chat_id      BIGINT    NOT NULL,
+    article_id   INT       NOT NULL,
+    queued_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP,
+    PRIMARY KEY (chat_id, article_id),
+    CONSTRAINT fk_subscription_deliveries_chat_id
+        FOREIGN KEY (chat_id)
+            REFERENCES subscribers (chat_id)
+            ON DELETE CASCADE,
+    CONSTRAINT fk_subscription_deliveries_article_id
+        FOREIGN KEY (article_id)
+            REFERENCES articles (id)
+            ON DELETE CASCADE
+);
+
+CREATE INDEX idx_subscription_deliveries_pending
+    ON subscription_deliveries (chat_id, queued_at)
+    WHERE delivered_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS subscription_deliveries;
+DROP TABLE IF EXISTS subscriptions;
+DROP TABLE IF EXISTS subscribers;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE articles
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', title), 'A') ||
+        setweight(to_tsvector('english', title), 'A') ||
+        setweight(to_tsvector('russian', summary), 'B') ||
+        setweight(to_tsvector('english', summary), 'B')
+    ) STORED;
+
+CREATE INDEX idx_articles_search_vector ON articles USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_articles_search_vector;
+
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS search_vector;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE deliveries
+(
+    id          SERIAL PRIMARY KEY,
+    article_id  INT       NOT NULL,
+    destination TEXT      NOT NULL,
+    chat_id     BIGINT    NOT NULL DEFAULT 0,
+    message_id  BIGINT    NOT NULL DEFAULT 0,
+    url         TEXT      NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_deliveries_article_id
+        FOREIGN KEY (article_id)
+            REFERENCES articles (id)
+            ON DELETE CASCADE
+);
+
+CREATE INDEX idx_deliveries_article_id ON deliveries (article_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS deliveries;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE articles
+    ADD COLUMN post_summary          TEXT,
+    ADD COLUMN moderation_status     TEXT   NOT NULL DEFAULT 'none',
+    ADD COLUMN moderated_by          BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN moderated_at          TIMESTAMP,
+    ADD CONSTRAINT chk_articles_moderation_status
+        CHECK (moderation_status IN ('none', 'pending', 'approved', 'rejected'));
+
+CREATE INDEX idx_articles_moderation_status ON articles (moderation_status) WHERE posted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_articles_moderation_status;
+
+ALTER TABLE articles
+    DROP CONSTRAINT IF EXISTS chk_articles_moderation_status,
+    DROP COLUMN IF EXISTS post_summary,
+    DROP COLUMN IF EXISTS moderation_status,
+    DROP COLUMN IF EXISTS moderated_by,
+    DROP COLUMN IF EXISTS moderated_at;
+-- +goose StatementEnd
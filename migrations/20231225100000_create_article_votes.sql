@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE article_votes
+(
+    article_id INT       NOT NULL,
+    user_id    BIGINT    NOT NULL,
+    value      SMALLINT  NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (article_id, user_id),
+    CONSTRAINT fk_article_votes_article_id
+        FOREIGN KEY (article_id)
+            REFERENCES articles (id)
+            ON DELETE CASCADE,
+    CONSTRAINT chk_article_votes_value CHECK (value IN (-1, 1))
+);
+
+-- score is the share of upvotes smoothed towards 0.5 for sources with few votes
+CREATE VIEW source_quality AS
+SELECT a.source_id,
+       COUNT(*) FILTER (WHERE v.value > 0)                                 AS upvotes,
+       COUNT(*) FILTER (WHERE v.value < 0)                                 AS downvotes,
+       (COUNT(*) FILTER (WHERE v.value > 0) + 1)::FLOAT / (COUNT(*) + 2) AS score
+FROM article_votes v
+JOIN articles a ON a.id = v.article_id
+GROUP BY a.source_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP VIEW IF EXISTS source_quality;
+DROP TABLE IF EXISTS article_votes;
+-- +goose StatementEnd
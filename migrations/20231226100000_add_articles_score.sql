@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE articles
+    ADD COLUMN score         DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN score_details JSONB            NOT NULL DEFAULT '[]',
+    ADD COLUMN scored_at     TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS scored_at,
+    DROP COLUMN IF EXISTS score_details,
+    DROP COLUMN IF EXISTS score;
+-- +goose StatementEnd
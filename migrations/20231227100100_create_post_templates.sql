@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE post_templates
+(
+    id         SERIAL PRIMARY KEY,
+    scope      TEXT      NOT NULL,
+    target_id  BIGINT    NOT NULL,
+    parse_mode TEXT      NOT NULL,
+    body       TEXT      NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_post_templates_scope_target UNIQUE (scope, target_id),
+    CONSTRAINT chk_post_templates_scope CHECK (scope IN ('channel', 'source'))
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS post_templates;
+-- +goose StatementEnd
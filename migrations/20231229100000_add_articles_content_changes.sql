@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the hash of the stored articles stays empty, the fetcher sets it on the next
+-- fetch without counting it as a change
+ALTER TABLE articles
+    ADD COLUMN content_hash       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN content_updated_at TIMESTAMP,
+    ADD COLUMN edited_at          TIMESTAMP,
+    ADD COLUMN edit_failures      INT NOT NULL DEFAULT 0,
+    ADD COLUMN edit_failed_at     TIMESTAMP,
+    ADD COLUMN retracted_at       TIMESTAMP;
+
+ALTER TABLE deliveries ADD COLUMN deleted_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE deliveries DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS retracted_at,
+    DROP COLUMN IF EXISTS edit_failed_at,
+    DROP COLUMN IF EXISTS edit_failures,
+    DROP COLUMN IF EXISTS edited_at,
+    DROP COLUMN IF EXISTS content_updated_at,
+    DROP COLUMN IF EXISTS content_hash;
+-- +goose StatementEnd
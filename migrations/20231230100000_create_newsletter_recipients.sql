@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE newsletter_recipients
+(
+    id              SERIAL PRIMARY KEY,
+    email           TEXT      NOT NULL UNIQUE,
+    token           TEXT      NOT NULL UNIQUE,
+    last_sent_at    TIMESTAMP,
+    unsubscribed_at TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS newsletter_recipients;
+-- +goose StatementEnd
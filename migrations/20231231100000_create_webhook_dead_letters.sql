@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE webhook_dead_letters
+(
+    id         SERIAL PRIMARY KEY,
+    event_id   TEXT      NOT NULL,
+    event      TEXT      NOT NULL,
+    url        TEXT      NOT NULL,
+    payload    JSONB     NOT NULL,
+    attempts   INT       NOT NULL,
+    last_error TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webhook_dead_letters;
+-- +goose StatementEnd